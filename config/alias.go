@@ -0,0 +1,101 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/NingziSlay/pkg/log"
+)
+
+// env 标签中 key 的分隔符，如 `env:"MQ_ADDR|RABBITMQ_URL"`，第一个是主 key，之后的是别名
+const (
+	aliasSep      = "|"
+	tagDeprecated = "deprecated"
+)
+
+// warnDeprecated 读取到已弃用的 key 时调用，测试中可以替换
+var warnDeprecated = func(key, replacement string) {
+	logger := log.GetLogger()
+	logger.Warn().Str("key", key).Str("replacement", replacement).
+		Msg("config - deprecated key is used, please rename it")
+}
+
+// parseAliases 解析 env 标签中的别名和 deprecated 标签，deprecated 标签中的 key 必须是别名
+func (t *data) parseAliases(deprecated string) error {
+	keys := strings.Split(t.key, aliasSep)
+	t.key, t.aliases = keys[0], keys[1:]
+	for _, key := range t.aliases {
+		if key == "" {
+			return newE("invalid env tag: empty alias")
+		}
+	}
+	if deprecated == "" {
+		return nil
+	}
+	t.deprecated = strings.Split(deprecated, aliasSep)
+	for _, key := range t.deprecated {
+		if !contains(t.aliases, key) {
+			return newE("invalid deprecated tag: %s is not an alias", key)
+		}
+	}
+	return nil
+}
+
+// addPrefix 给 key 和所有别名加上前缀
+func (t *data) addPrefix(prefix string) {
+	t.key = prefix + t.key
+	if prefix == "" {
+		return
+	}
+	aliases := make([]string, 0, len(t.aliases))
+	for _, key := range t.aliases {
+		aliases = append(aliases, prefix+key)
+	}
+	deprecated := make([]string, 0, len(t.deprecated))
+	for _, key := range t.deprecated {
+		deprecated = append(deprecated, prefix+key)
+	}
+	t.aliases, t.deprecated = aliases, deprecated
+}
+
+// lookupAlias 依次从 t.key 和别名加上 suffix 查找值，返回第一个存在的 key 对应的值，
+// 使用了已弃用的 key 时会输出一条警告日志
+func (m *mapper) lookupAlias(t *data, suffix string) (string, bool) {
+	m.markKnown(t.keys(suffix)...)
+	if val, s, ok := lookupSource(m.sources, t.key+suffix); ok {
+		m.setOrigin(t, s, t.key+suffix)
+		t.setExported(s, suffix)
+		return val, true
+	}
+	for _, key := range t.aliases {
+		val, s, ok := lookupSource(m.sources, key+suffix)
+		if !ok {
+			continue
+		}
+		if contains(t.deprecated, key) {
+			warnDeprecated(key+suffix, t.key+suffix)
+		}
+		m.setOrigin(t, s, key+suffix)
+		t.setExported(s, suffix)
+		return val, true
+	}
+	return "", false
+}
+
+// keys 返回 t.key 和所有别名加上 suffix 之后的 key
+func (t *data) keys(suffix string) []string {
+	result := make([]string, 0, len(t.aliases)+1)
+	result = append(result, t.key+suffix)
+	for _, key := range t.aliases {
+		result = append(result, key+suffix)
+	}
+	return result
+}
+
+func contains(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
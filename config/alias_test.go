@@ -0,0 +1,71 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMapperAlias(t *testing.T) {
+	var warnings []string
+	warn := warnDeprecated
+	warnDeprecated = func(key, replacement string) {
+		warnings = append(warnings, key+"->"+replacement)
+	}
+	defer func() { warnDeprecated = warn }()
+
+	type Config struct {
+		Addr     string `env:"MQ_ADDR|RABBITMQ_URL|AMQP_URL,amqp://localhost" deprecated:"AMQP_URL"`
+		Password string `env:"PASSWORD|DB_PASS" deprecated:"DB_PASS" secret:"true"`
+		Name     string `env:"|SERVICE"`
+	}
+	var cases = []struct {
+		values   map[string]string
+		expect   Config
+		warnings []string
+	}{
+		{
+			values: map[string]string{},
+			expect: Config{Addr: "amqp://localhost"},
+		},
+		{
+			values: map[string]string{"MQ_ADDR": "a", "RABBITMQ_URL": "b", "AMQP_URL": "c"},
+			expect: Config{Addr: "a"},
+		},
+		{
+			values: map[string]string{"RABBITMQ_URL": "b", "AMQP_URL": "c", "SERVICE": "pkg"},
+			expect: Config{Addr: "b", Name: "pkg"},
+		},
+		{
+			values:   map[string]string{"APP_AMQP_URL": "c", "APP_DB_PASS_FILE": writeFile(t, "password", "p@ss")},
+			expect:   Config{Addr: "c", Password: "p@ss"},
+			warnings: []string{"APP_AMQP_URL->APP_MQ_ADDR", "APP_DB_PASS_FILE->APP_PASSWORD_FILE"},
+		},
+	}
+	for i, c := range cases {
+		warnings = nil
+		var config Config
+		loader := &Loader{Sources: []Source{Map(c.values)}}
+		if i == len(cases)-1 {
+			loader.Prefix = "APP_"
+		}
+		if err := loader.Load(&config); err != nil {
+			t.Fatalf("unexpected error: %v, index: %d", err, i)
+		}
+		if config != c.expect || !reflect.DeepEqual(warnings, c.warnings) {
+			t.Fatalf("unexpected result: %+v, %v, index: %d", config, warnings, i)
+		}
+	}
+
+	type Invalid struct {
+		Addr string `env:"MQ_ADDR|RABBITMQ_URL" deprecated:"AMQP_URL"`
+	}
+	if err := MapFrom(&Invalid{}, nil); err == nil {
+		t.Fatalf("expect error returned")
+	}
+	type Empty struct {
+		Addr string `env:"MQ_ADDR|"`
+	}
+	if err := MapFrom(&Empty{}, nil); err == nil {
+		t.Fatalf("expect error returned")
+	}
+}
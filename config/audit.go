@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/NingziSlay/pkg/log"
+)
+
+// audit Loader.Audit 记录的一个配置项
+type audit struct {
+	key    string
+	value  string // 敏感字段已经替换为 ******
+	origin string
+}
+
+// sourceName 返回 Source 的名字，没有实现 Name() 时返回类型名
+func sourceName(s Source) string {
+	if n, ok := s.(interface{ Name() string }); ok {
+		return n.Name()
+	}
+	return fmt.Sprintf("%T", s)
+}
+
+// setOrigin 记录 t 的值来自哪个 Source，key 和字段的 key 不同时（别名、KEY_FILE）带上实际的 key
+func (m *mapper) setOrigin(t *data, s Source, key string) {
+	if !m.audit {
+		return
+	}
+	t.origin = sourceName(s)
+	if key != t.key {
+		t.origin += " (" + key + ")"
+	}
+}
+
+// defaultOrigin 使用默认值时的来源，default 或 default.<profile>
+func (m *mapper) defaultOrigin(t *data) string {
+	if m.profile != "" {
+		if _, ok := t.typ.Tag.Lookup(tagDefault + profileSep + m.profile); ok {
+			return tagDefault + profileSep + m.profile
+		}
+	}
+	return tagDefault
+}
+
+// record 记录字段最终的值和来源
+func (m *mapper) record(t *data) {
+	if !m.audit {
+		return
+	}
+	value := t.val
+	if t.secret && value != "" {
+		value = mask
+	}
+	origin := t.origin
+	if origin == "" {
+		origin = "unset"
+	}
+	m.audits = append(m.audits, audit{key: t.key, value: value, origin: origin})
+}
+
+// logAudit 输出一个配置项的值和来源，测试中可以替换
+var logAudit = func(a audit) {
+	logger := log.GetLogger()
+	logger.Info().Str("key", a.key).Str("value", a.value).Str("source", a.origin).Msg("config")
+}
+
+// logAudits 通过 log 包输出记录的所有配置项
+func (m *mapper) logAudits() {
+	if !m.audit {
+		return
+	}
+	for _, a := range m.audits {
+		logAudit(a)
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoaderAudit(t *testing.T) {
+	var audits []audit
+	fn := logAudit
+	logAudit = func(a audit) {
+		audits = append(audits, a)
+	}
+	defer func() { logAudit = fn }()
+
+	type Config struct {
+		Host     string `env:"HOST,localhost"`
+		Port     int    `env:"PORT,80" default.prod:"443"`
+		Addr     string `env:"ADDR|URL"`
+		Password string `env:"PASSWORD" secret:"true"`
+		Token    Secret `env:"TOKEN"`
+		DB       struct {
+			Name string `env:"NAME"`
+		} `env:"DB"`
+		Empty string `env:"EMPTY"`
+	}
+	file, err := DotEnvFile(writeFile(t, ".env", "APP_URL=http://example.com\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	loader := &Loader{
+		Sources: []Source{
+			Map(map[string]string{"APP_HOST": "db", "APP_PASSWORD_FILE": writeFile(t, "password", "p@ss")}),
+			file,
+		},
+		Prefix:     "APP_",
+		AutoPrefix: true,
+		Profile:    "prod",
+		Audit:      true,
+	}
+	var c Config
+	if err := loader.Load(&c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := []audit{
+		{key: "APP_HOST", value: "db", origin: "map"},
+		{key: "APP_PORT", value: "443", origin: "default.prod"},
+		{key: "APP_ADDR", value: "http://example.com", origin: "file:" + file.Path() + " (APP_URL)"},
+		{key: "APP_PASSWORD", value: mask, origin: "map (APP_PASSWORD_FILE)"},
+		{key: "APP_TOKEN", value: "", origin: "unset"},
+		{key: "APP_DB_NAME", value: "", origin: "unset"},
+		{key: "APP_EMPTY", value: "", origin: "unset"},
+	}
+	if !reflect.DeepEqual(audits, expect) {
+		t.Fatalf("unexpected audits: %+v", audits)
+	}
+
+	// 默认不输出
+	audits = nil
+	loader.Audit = false
+	if err := loader.Load(&c); err != nil || len(audits) != 0 {
+		t.Fatalf("unexpected audits: %+v, %v", audits, err)
+	}
+}
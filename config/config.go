@@ -1,7 +1,7 @@
 package config
 
 import (
-	"os"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
@@ -14,24 +14,38 @@ import (
 // 如果 dest 为 nil，返回 ErrorNilInput
 // 如果 dest 不是一个指针，返回 ErrorNonPointer
 // 如果 dest 指针指向的值不是一个结构体，返回 ErrorNonStruct
-// 如果环境变量的值转为字段相应类型时发生错误，立即返回相应的错误
+// 如果环境变量的值转为字段相应类型时发生错误，会继续处理剩余的字段，最后以 Errors 的形式
+// 返回所有字段的错误，每个错误都是一个 ConfigError，包含字段路径、环境变量名、原始值和错误原因
 //
-// MapConfig 允许字段值为空
+// MapConfig 允许字段值为空，相当于 Load(dest, Env())，需要从其他来源读取配置时使用 Load 或 Loader
 func MapConfig(dest interface{}) error {
-	return newMapper(false).mapper(dest)
+	return newMapper(false, Env()).mapper(dest)
 }
 
 // MustMapConfig 所有可导出字段都不允许为空
 func MustMapConfig(dest interface{}) error {
-	return newMapper(true).mapper(dest)
+	return newMapper(true, Env()).mapper(dest)
 }
 
 type mapper struct {
-	strict bool
+	strict          bool
+	sources         []Source            // 按优先级从高到低排列的配置来源
+	prefix          string              // 所有 key 的前缀，如 APP_
+	autoPrefix      bool                // 嵌套结构体的字段是否自动加上父字段的 key 作为前缀
+	allowEmpty      bool                // 设置为空的 key 是否使用空值，而不是默认值
+	disallowUnknown bool                // 是否检查带有 prefix 前缀但没有被读取的 key
+	known           map[string]struct{} // 读取过的 key，disallowUnknown 为 true 时才会记录
+	profile         string              // 当前的 profile，default.<profile> 标签优先于 default 标签
+	audit           bool                // 是否记录每个字段的值和来源，Load 成功之后输出日志
+	audits          []audit             // audit 为 true 时记录的字段
+	keys            []string            // sources 中所有的 key，扫描带下标的 key 时才会读取
+	typ             reflect.Type        // dest 指向的结构体类型
+	secrets         map[string]struct{} // 敏感字段的 key，展开引用时才会读取，参考 isSecretKey
+	errs            Errors              // mapper 过程中收集到的字段错误
 }
 
-func newMapper(strict bool) *mapper {
-	return &mapper{strict: strict}
+func newMapper(strict bool, sources ...Source) *mapper {
+	return &mapper{strict: strict, sources: sources}
 }
 
 /* dest 必须是一个指向结构体的指针
@@ -74,7 +88,14 @@ func (m *mapper) mapper(dest interface{}) error {
 	if v.Kind() != reflect.Struct {
 		return ErrorNonStruct
 	}
-	return m.mapStruct(v)
+	m.typ = v.Type()
+	m.mapStruct(v, v.Type().Name(), m.prefix)
+	m.checkUnknown()
+	if len(m.errs) > 0 {
+		return m.errs
+	}
+	m.logAudits()
+	return nil
 }
 
 // behind 返回 v 指针指向的最终值，如果 v 本身就是一个指针，就直接返回 v 自身
@@ -90,40 +111,129 @@ func behind(v reflect.Value) reflect.Value {
 	return behind(reflect.Indirect(v))
 }
 
-// mapStruct 结构体内的字段处理
-func (m *mapper) mapStruct(v reflect.Value) error {
-	t := v.Type()
-	for i := 0; i < v.NumField(); i++ {
-		ft := t.Field(i)
-		fv := v.Field(i)
+// mapStruct 结构体内的字段处理，path 是 v 在整个配置结构体中的路径，prefix 是 v 中所有字段 key 的前缀
+// 字段的错误不会中断处理，而是收集到 m.errs 中
+func (m *mapper) mapStruct(v reflect.Value, path, prefix string) {
+	n := len(m.errs)
+	// 没有出错的字段，赋值完成之后统一校验
+	checks := make([]*data, 0, v.NumField())
+	// 标签为 "-" 的字段和非导出字段已经被排除
+	for _, data := range fields(v.Type()) {
+		ft := data.typ
+		fv := data.field(v)
+
+		data.path = joinPath(path, ft.Name)
+		data.prefix = prefix + m.nestedPrefix(data)
+		data.addPrefix(prefix)
+		data.addKindSuffix()
+		if data.tagErr != nil {
+			m.errs = append(m.errs, fieldE(data, data.tagErr))
+			continue
+		}
+		if err := m.resolve(data); err != nil {
+			m.errs = append(m.errs, fieldE(data, err))
+			continue
+		}
 
-		data := getData(ft)
-		// 标签为 "-" 或非导出字段
-		if data.shouldSkip() {
+		// 结构体切片从带下标的 key 中读取，如 SERVERS_0_HOST、SERVERS_1_HOST
+		if isStructSlice(ft.Type) && !data.isJSON() {
+			if m.setStructSlice(fv, data) {
+				checks = append(checks, data)
+			} else if m.isRequired(data) {
+				m.errs = append(m.errs, fieldE(data, newE("missing value")))
+			}
 			continue
 		}
 
 		// 如果字段的 env 值为空，判断是否是结构体，如果是结构体则忽略，否则根据 strict 判断是否返回错误
 		if !data.isValid() {
-			if behind(fv).Kind() != reflect.Struct {
-				if m.strict {
-					return newE("missing value of %s.%s", t.String(), ft.Name)
+			if data.isJSON() || !isStruct(behind(fv).Type()) {
+				if data.exists && m.isAllowEmpty(data) {
+					// 明确设置为空，使用零值覆盖字段原有的值
+					fv.Set(reflect.Zero(fv.Type()))
+					checks = append(checks, data)
+					m.record(data)
+				} else if m.isRequired(data) {
+					m.errs = append(m.errs, fieldE(data, data.emptyE()))
+				} else {
+					// 没有设置的可选字段不做 validate 校验
+					m.record(data)
 				}
 				continue
 			}
 		}
 		// set value
-		if err := m.setFieldValue(fv, data.val); err != nil {
-			return err
+		if err := m.setFieldValue(fv, data.val, data); err != nil {
+			m.errs = append(m.errs, fieldE(data, err))
+			continue
+		}
+		checks = append(checks, data)
+		// 嵌套结构体中的字段已经单独记录
+		if data.isJSON() || !isStruct(behind(fv).Type()) {
+			m.record(data)
 		}
 	}
-	return nil
+
+	// 所有字段赋值之后再校验，gt、lt 等规则需要和其他字段比较
+	for _, data := range checks {
+		if err := m.validate(v, data); err != nil {
+			m.errs = append(m.errs, fieldE(data, err))
+		}
+	}
+	// 结构体内的字段都没有错误时，才调用结构体自身的 Validate 方法
+	if len(m.errs) == n {
+		if err := m.callValidator(v); err != nil {
+			m.errs = append(m.errs, ConfigError{Path: path, Msg: "Validate", Err: err})
+		}
+	}
+}
+
+// nestedPrefix 返回嵌套结构体字段中 key 的前缀（不包括父结构体的前缀）
+// 优先使用 prefix 标签，否则如果开启了 autoPrefix，使用字段自身的 key 加上 "_"
+func (m *mapper) nestedPrefix(d *data) string {
+	if d.hasPrefix {
+		return d._prefix
+	}
+	// 和 encoding/json 一样，没有设置 key 的匿名字段展开到父结构体中，不加前缀
+	if d.embedded {
+		return ""
+	}
+	if m.autoPrefix {
+		return d.key + "_"
+	}
+	return ""
+}
+
+// joinPath 拼接字段路径
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
 }
 
 // setFieldValue 给结构体字段赋值
 // 如果 v 不可寻址或是不可导出字段（字段首字母小写），返回 ErrorNotWritable 错误
 // 给结构体赋值需要转换为对应类型，如果类型转换错误，返回相应的错误
-func (m *mapper) setFieldValue(v reflect.Value, value string) error {
+// d 是字段的标签信息，time.Time 等类型需要从中读取额外的参数
+func (m *mapper) setFieldValue(v reflect.Value, value string, d *data) error {
+	// 优先级：format:"json" > 注册的 DecodeFunc > time.Duration 等标准库类型 > encoding.TextUnmarshaler >
+	// 其他 format 标签 > Kind
+	if d.isJSON() {
+		return m.setJSON(v, value)
+	}
+	if decoder, ok := getDecoder(v.Type()); ok {
+		return m.setDecoded(v, value, decoder)
+	}
+	if handled, err := m.setStdType(v, value, d); handled {
+		return err
+	}
+	if handled, err := m.setTextUnmarshaler(v, value); handled {
+		return err
+	}
+	if handled, err := m.setFormatted(v, value, d); handled {
+		return err
+	}
 	switch v.Kind() {
 	default:
 		return newE("unsupported type: %s", v.String())
@@ -152,13 +262,17 @@ func (m *mapper) setFieldValue(v reflect.Value, value string) error {
 	case reflect.Bool:
 		return m.setBool(v, value)
 	case reflect.Slice:
-		return m.setSlice(v, value)
+		return m.setSlice(v, value, d)
 	case reflect.Array:
-		return m.setArray(v, value)
+		return m.setArray(v, value, d)
+	case reflect.Map:
+		return m.setMap(v, value, d)
+	case reflect.Interface:
+		return m.setInterface(v, value, d)
 	case reflect.Ptr:
-		return m.setPtr(v, value)
+		return m.setPtr(v, value, d)
 	case reflect.Struct:
-		return m.setStruct(v)
+		return m.setStruct(v, d)
 	}
 	return nil
 }
@@ -283,12 +397,12 @@ func (m *mapper) setBool(v reflect.Value, value string) error {
 }
 
 // setSlice 设置 slice 类型
-func (m *mapper) setSlice(v reflect.Value, value string) error {
-	tags := strings.Split(value, ",")
+func (m *mapper) setSlice(v reflect.Value, value string, d *data) error {
+	tags := strings.Split(value, d.separator())
 	slice := reflect.MakeSlice(v.Type(), len(tags), cap(tags))
 	for i, t := range tags {
 		elem := slice.Index(i)
-		err := m.setFieldValue(elem, t)
+		err := m.setFieldValue(elem, t, d)
 		if err != nil {
 			return wrapE("setSlice", err)
 		}
@@ -298,13 +412,13 @@ func (m *mapper) setSlice(v reflect.Value, value string) error {
 }
 
 // setArray 设置 array 类型
-func (m *mapper) setArray(v reflect.Value, value string) error {
-	tags := strings.Split(value, ",")
+func (m *mapper) setArray(v reflect.Value, value string, d *data) error {
+	tags := strings.Split(value, d.separator())
 	if len(tags) > v.Cap() {
 		return newE("array out of range, max: %d", v.Cap())
 	}
 	for i, t := range tags {
-		err := m.setFieldValue(v.Index(i), t)
+		err := m.setFieldValue(v.Index(i), t, d)
 		if err != nil {
 			return wrapE("setArray", err)
 		}
@@ -312,25 +426,182 @@ func (m *mapper) setArray(v reflect.Value, value string) error {
 	return nil
 }
 
+// setMap 设置 map 类型，格式为 k1=v1,k2=v2（分隔符可以通过 sep 标签修改），
+// key 和 value 都会转为 map 对应的类型，结构体、切片等无法用一个值表示的类型需要使用 format:"json"
+func (m *mapper) setMap(v reflect.Value, value string, d *data) error {
+	t := v.Type()
+	if !isScalar(t.Key()) || !isScalar(t.Elem()) {
+		return newE("setMap: unsupported type %s, use format:\"json\" instead", t)
+	}
+	pairs := strings.Split(value, d.separator())
+	result := reflect.MakeMapWithSize(t, len(pairs))
+	for _, pair := range pairs {
+		i := strings.Index(pair, "=")
+		if i < 0 {
+			return newE("setMap: invalid pair %q, expect key=value", pair)
+		}
+		key := reflect.New(t.Key()).Elem()
+		if err := m.setFieldValue(key, strings.TrimSpace(pair[:i]), d); err != nil {
+			return wrapE("setMap", err)
+		}
+		elem := reflect.New(t.Elem()).Elem()
+		if err := m.setFieldValue(elem, strings.TrimSpace(pair[i+1:]), d); err != nil {
+			return wrapE("setMap", err)
+		}
+		result.SetMapIndex(key, elem)
+	}
+	v.Set(result)
+	return nil
+}
+
+// isScalar 判断 t 是否可以用一个值表示，如数字、字符串、time.Duration、实现了 encoding.TextUnmarshaler 的类型
+func isScalar(t reflect.Type) bool {
+	t = indirectType(t)
+	if isStdType(t) || isTextUnmarshaler(t) {
+		return true
+	}
+	if _, ok := getDecoder(t); ok {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return false
+	case reflect.Interface:
+		return !hasFactory(t)
+	}
+	return true
+}
+
+// setInterface 设置 interface{} 类型，直接保存字符串，如 amqp.Table 中的值
+func (m *mapper) setInterface(v reflect.Value, value string, d *data) error {
+	if d != nil && hasFactory(v.Type()) {
+		return m.setImpl(v, value, d)
+	}
+	if v.NumMethod() > 0 {
+		return newE("unsupported type: %s", v.Type())
+	}
+	v.Set(reflect.ValueOf(value))
+	return nil
+}
+
+// setStructSlice 设置结构体切片，通过扫描所有 key 找到带下标的 key 确定切片的长度，
+// 第 i 个元素中字段的 key 的前缀为 d.key_i_，如 SERVERS_0_HOST
+// 没有找到任何带下标的 key 时返回 false
+func (m *mapper) setStructSlice(v reflect.Value, d *data) bool {
+	n := m.countIndexed(d.key + "_")
+	if n == 0 {
+		return false
+	}
+	slice := reflect.MakeSlice(v.Type(), n, n)
+	for i := 0; i < n; i++ {
+		path := fmt.Sprintf("%s[%d]", d.path, i)
+		prefix := fmt.Sprintf("%s_%d_", d.key, i)
+		m.mapStruct(behind(slice.Index(i)), path, prefix)
+	}
+	v.Set(slice)
+	return true
+}
+
+// countIndexed 返回以 prefix 开头、紧跟着下标和 "_" 的 key 中最大的下标加一
+func (m *mapper) countIndexed(prefix string) int {
+	if m.keys == nil {
+		m.keys = keys(m.sources)
+	}
+	n := 0
+	for _, key := range m.keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		rest := key[len(prefix):]
+		i := strings.Index(rest, "_")
+		if i <= 0 {
+			continue
+		}
+		index, err := strconv.Atoi(rest[:i])
+		if err != nil || index < 0 {
+			continue
+		}
+		if index+1 > n {
+			n = index + 1
+		}
+	}
+	return n
+}
+
 // setPtr 设置 ptr 类型
-func (m *mapper) setPtr(v reflect.Value, value string) error {
+func (m *mapper) setPtr(v reflect.Value, value string, d *data) error {
 	v = behind(v)
-	return m.setFieldValue(v, value)
+	return m.setFieldValue(v, value, d)
 }
 
-// setStruct 设置 struct 类型
-func (m *mapper) setStruct(v reflect.Value) error {
-	return m.mapStruct(v)
+// setStruct 设置 struct 类型，结构体内字段的错误直接收集到 m.errs 中
+func (m *mapper) setStruct(v reflect.Value, d *data) error {
+	m.mapStruct(v, d.path, d.prefix)
+	return nil
 }
 
-const tagName = "env"
+// 结构体标签，env 标签有以下几种写法：
+//
+//	`env:"KEY,default"`                               默认值是第一个 `,` 之后的所有内容
+//	`env:"KEY" default:"a,b" sep:";" required:"true"`  设置了 default 标签时，env 标签只包含 key，不能再包含默认值
+//	`env:"KEY|OLD_KEY" deprecated:"OLD_KEY"`           KEY 不存在时依次查找别名，使用 deprecated 中的别名时输出警告日志
+//
+// 其他标签：
+//
+//	default  默认值
+//	default.<profile> 使用 Loader.Profile 时的默认值，如 `default.prod:"..."`，优先于 default 标签
+//	sep      slice、array、map 的分隔符，默认为 `,`
+//	required true 或 false，优先级高于 MustMapConfig / MapConfig 的设置
+//	trim     为 false 时不去掉值两端的空格
+//	deprecated 已弃用的别名，以 | 分隔，必须是 env 标签中的别名
+//	allowEmpty 为 true 时，key 设置为空（如 KEY=）会使用空值而不是默认值，优先级高于 Loader.AllowEmpty
+//	secret   为 true 时表示敏感字段，会额外从 KEY_FILE 指向的文件读取值，错误信息和 Sprint 中的值会被替换为 ******
+//	layout   time.Time 的解析格式
+//	format   数字的解析格式：bytes（64MiB）、literal（0x1F、1_000_000）、percent（50%），参考 setFormatted
+//	         json 表示使用 encoding/json 解析整个值，适用于结构体、map、结构体切片等无法用分隔符表示的类型
+//	prefix   嵌套结构体中字段 key 的前缀
+//	validate 校验规则，参考 rule
+const (
+	tagName       = "env"
+	tagDefault    = "default"
+	tagSep        = "sep"
+	tagRequired   = "required"
+	tagTrim       = "trim"
+	tagLayout     = "layout"
+	tagAllowEmpty = "allowEmpty"
+	tagPrefix     = "prefix"
+)
+
+// defaultSep slice、array、map 默认的分隔符
+const defaultSep = ","
 
 type data struct {
-	typ      reflect.StructField // field type
-	key      string              // env key
-	val      string              // env value
-	_default string              // default value, use replace when val is empty
-	skip     bool                // - 则直接跳过
+	typ           reflect.StructField // field type
+	key           string              // env key
+	aliases       []string            // env 标签中 key 之后以 | 分隔的别名，key 不存在时依次查找
+	deprecated    []string            // deprecated 标签中的别名，使用时输出警告日志
+	val           string              // env value
+	_default      string              // default value, use replace when val is empty
+	sep           string              // slice、array、map 的分隔符
+	required      bool                // required 标签的值
+	hasReq        bool                // 是否设置了 required 标签，没有设置时根据 strict 判断
+	allowEmpty    bool                // allowEmpty 标签的值
+	hasAllowEmpty bool                // 是否设置了 allowEmpty 标签，没有设置时根据 mapper 的配置判断
+	exists        bool                // key 是否存在于 sources 中，用于区分没有设置和设置为空
+	exported      bool                // 值来自 Export 导出的 Vars，空值也是字段原来的值
+	origin        string              // 值的来源，只在 Loader.Audit 为 true 时记录，参考 setOrigin
+	trim          bool                // 是否去掉值两端的空格
+	secret        bool                // 是否是敏感字段，错误信息中的值会被替换为 ******
+	layout        string              // time.Time 的解析格式，为空时使用 time.RFC3339
+	format        string              // format 标签的值，数字的解析格式
+	rules         []rule              // validate 标签解析出的校验规则
+	tagErr        error               // 标签解析失败的错误
+	path          string              // 字段在结构体中的路径，如 Config.DB.Port
+	prefix        string              // 嵌套结构体中字段 key 的完整前缀
+	_prefix       string              // prefix 标签的值
+	hasPrefix     bool                // 是否设置了 prefix 标签，prefix:"" 表示不使用前缀
+	embedded      bool                // 是否是没有设置 key 的匿名字段，匿名结构体中的字段不加前缀
+	skip          bool                // - 则直接跳过
 }
 
 func getData(field reflect.StructField) (t *data) {
@@ -347,9 +618,15 @@ func getData(field reflect.StructField) (t *data) {
 		return
 	}
 
-	// 标签为空，默认使用字段名下划线大写命名作为默认环境变量名
-	if tag == "" {
-		t.key = camelCaseToUnderscoreUpper(field.Name)
+	// 设置了 default 标签时，env 标签只包含 key，否则 env 标签中第一个 `,` 之后的内容是默认值
+	var hasDefault bool
+	if t._default, hasDefault = field.Tag.Lookup(tagDefault); hasDefault {
+		// 同时设置两种默认值时，无法确定使用哪一个
+		if strings.Contains(tag, ",") {
+			t.tagErr = newE("default value is set in both env tag and default tag")
+			return t
+		}
+		t.key = tag
 	} else {
 		tags := strings.SplitN(tag, ",", 2)
 		t.key = tags[0]
@@ -357,15 +634,132 @@ func getData(field reflect.StructField) (t *data) {
 			t._default = tags[1]
 		}
 	}
+	// key 之后可以有多个以 | 分隔的别名
+	if err := t.parseAliases(field.Tag.Get(tagDeprecated)); err != nil {
+		t.tagErr = err
+		return t
+	}
+	// 标签为空，默认使用字段名下划线大写命名作为默认环境变量名
+	if t.key == "" {
+		t.embedded = field.Anonymous
+		t.key = camelCaseToUnderscoreUpper(field.Name)
+	}
+
+	t.sep = field.Tag.Get(tagSep)
+	if t.sep == "" {
+		t.sep = defaultSep
+	}
+	t.trim = true
+	if required, ok := field.Tag.Lookup(tagRequired); ok {
+		t.hasReq = true
+		if t.required, t.tagErr = strconv.ParseBool(required); t.tagErr != nil {
+			t.tagErr = wrapE("invalid required tag", t.tagErr)
+			return t
+		}
+	}
+	if allowEmpty, ok := field.Tag.Lookup(tagAllowEmpty); ok {
+		t.hasAllowEmpty = true
+		if t.allowEmpty, t.tagErr = strconv.ParseBool(allowEmpty); t.tagErr != nil {
+			t.tagErr = wrapE("invalid allowEmpty tag", t.tagErr)
+			return t
+		}
+	}
+	if trim, ok := field.Tag.Lookup(tagTrim); ok {
+		if t.trim, t.tagErr = strconv.ParseBool(trim); t.tagErr != nil {
+			t.tagErr = wrapE("invalid trim tag", t.tagErr)
+			return t
+		}
+	}
 
-	t.val = os.Getenv(t.key)
-	if t.val == "" {
-		t.val = t._default
+	if t.secret, t.tagErr = parseSecret(field); t.tagErr != nil {
+		return t
+	}
+	t.layout = field.Tag.Get(tagLayout)
+	if t.format = field.Tag.Get(tagFormat); !isFormat(t.format) {
+		t.tagErr = newE("invalid format tag: %s", t.format)
+		return t
+	}
+	t._prefix, t.hasPrefix = field.Tag.Lookup(tagPrefix)
+	if t.rules, t.tagErr = parseRules(field.Tag.Get(tagValidate)); t.tagErr == nil {
+		t.tagErr = checkRules(t.rules, field.Type)
 	}
-	t.val = strings.Trim(t.val, " ")
 	return t
 }
 
+// resolve 从 m.sources 中读取 t.key 对应的值，t.key 不存在时依次查找别名，都不存在时使用默认值，
+// key 存在但是值为空时，只有允许为空（参考 isAllowEmpty）才使用空值，否则同样使用默认值
+// 敏感字段的 key 没有值时，会从 KEY_FILE 指向的文件中读取
+// 值和默认值中的 ${KEY}、${KEY:-fallback} 会被展开，参考 interpolate，敏感字段只展开默认值
+func (m *mapper) resolve(t *data) error {
+	t.val, t.exists = m.lookupAlias(t, "")
+	unset := !t.exists || (t.val == "" && !m.isAllowEmpty(t))
+	if t.secret {
+		m.markKnown(t.keys(fileSuffix)...)
+	}
+	if unset && t.secret {
+		if path, _ := m.lookupAlias(t, fileSuffix); path != "" {
+			val, err := readSecretFile(path)
+			if err != nil {
+				return err
+			}
+			t.val, t.exists, unset = val, true, false
+		}
+	}
+	def := m.defaultValue(t)
+	useDefault := unset && def != ""
+	if useDefault {
+		t.val, t.origin = def, m.defaultOrigin(t)
+	}
+	// 展开值和默认值中的 ${KEY}，敏感字段的值（如密码）中可能包含 ${，只展开默认值
+	if !t.secret || useDefault {
+		val, err := m.interpolate(t.val, []string{t.key})
+		if err != nil {
+			return err
+		}
+		t.val = val
+	}
+	if t.trim {
+		t.val = strings.Trim(t.val, " ")
+	}
+	return nil
+}
+
+// isRequired 字段是否不允许为空，required 标签优先于 strict
+func (m *mapper) isRequired(t *data) bool {
+	if t.hasReq {
+		return t.required
+	}
+	return m.strict
+}
+
+// isAllowEmpty 字段的 key 设置为空时是否使用空值，allowEmpty 标签优先于 Loader.AllowEmpty，
+// 来自 Vars 的空值总是允许
+func (m *mapper) isAllowEmpty(t *data) bool {
+	if t.exported {
+		return true
+	}
+	if t.hasAllowEmpty {
+		return t.allowEmpty
+	}
+	return m.allowEmpty
+}
+
+// emptyE 字段值为空时的错误，区分 key 没有设置和设置为空
+func (t *data) emptyE() error {
+	if t.exists {
+		return newE("empty value")
+	}
+	return newE("missing value")
+}
+
+// separator 返回 slice、array、map 的分隔符
+func (t *data) separator() string {
+	if t == nil || t.sep == "" {
+		return defaultSep
+	}
+	return t.sep
+}
+
 func (t *data) shouldSkip() bool {
 	return t.skip
 }
@@ -374,6 +768,25 @@ func (t *data) isValid() bool {
 	return t.val != ""
 }
 
+// isStructSlice 判断 t 是否是结构体切片（包括结构体指针切片）
+func isStructSlice(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice {
+		return false
+	}
+	return isStruct(indirectType(t.Elem()))
+}
+
+// isStruct 判断 t 是否是需要逐个字段解析的结构体，
+// time.Time 等标准库类型、注册了 DecodeFunc 或实现了 encoding.TextUnmarshaler 的类型
+// 虽然是结构体，但是作为一个整体赋值
+func isStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || isStdType(t) || isTextUnmarshaler(t) {
+		return false
+	}
+	_, ok := getDecoder(t)
+	return !ok
+}
+
 // 驼峰单词转下划线单词
 func camelCaseToUnderscoreUpper(s string) string {
 	var output []rune
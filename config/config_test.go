@@ -1,11 +1,15 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"math"
-	"os"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/NingziSlay/pkg/config/configtest"
 )
 
 func TestMapper_Parse(t *testing.T) {
@@ -40,8 +44,8 @@ func TestMapperUnexported(t *testing.T) {
 	}
 	var c Config
 	var c1 Config
-	_ = MustMapConfig(&c)
-	_ = MapConfig(&c1)
+	_ = MustMapFrom(&c, nil)
+	_ = MapFrom(&c1, nil)
 
 	if c.url != "" {
 		t.Fatalf("unexported failed should be ignore")
@@ -53,14 +57,11 @@ func TestMapperUnexported(t *testing.T) {
 
 // 测试从环境变量读取
 func TestMapperENV(t *testing.T) {
-	defer os.Clearenv()
 	type Config struct {
 		Name string `env:"LIST,hello"`
 	}
 	var c Config
-	if err := os.Setenv("LIST", "world"); err != nil {
-		panic(err)
-	}
+	configtest.Setenv(t, map[string]string{"LIST": "world"})
 	_ = MustMapConfig(&c)
 	if c.Name != "world" {
 		t.Fatalf("unexpected value")
@@ -69,13 +70,10 @@ func TestMapperENV(t *testing.T) {
 
 // 测试 "-" 标签
 func TestMapperSkip(t *testing.T) {
-	defer os.Clearenv()
 	type Config struct {
 		Name string `env:"-"`
 	}
-	if err := os.Setenv("NAME", "should be ignored"); err != nil {
-		panic(err)
-	}
+	configtest.Setenv(t, map[string]string{"NAME": "should be ignored"})
 	var c Config
 	_ = MustMapConfig(&c)
 	if c.Name != "" {
@@ -85,7 +83,7 @@ func TestMapperSkip(t *testing.T) {
 
 // 测试数值类型
 func TestMapperInteger(t *testing.T) {
-	defer os.Clearenv()
+	t.Parallel()
 	type Int8 struct {
 		Int8 int8
 	}
@@ -111,187 +109,151 @@ func TestMapperInteger(t *testing.T) {
 	var cases = []struct {
 		input    interface{}
 		err      bool
-		setter   func()
-		function func(interface{}) error
+		env      map[string]string
+		function func(interface{}, map[string]string) error
 	}{
 		{
 			input:    &i8,
 			err:      false,
-			function: MapConfig, // 忽略空值
+			function: MapFrom, // 忽略空值
 		},
 		{
 			input:    &i8,
 			err:      true,
-			function: MustMapConfig, // 空值返回错误
+			function: MustMapFrom, // 空值返回错误
 		},
 		{
-			input: &i8,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT8", strconv.Itoa(math.MaxInt8+1)) // out of range
-			},
-			function: MustMapConfig,
+			input:    &i8,
+			err:      true,
+			env:      map[string]string{"INT8": strconv.Itoa(math.MaxInt8 + 1)}, // out of range
+			function: MustMapFrom,
 		},
 		{
-			input: &i8,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT8", strconv.Itoa(math.MaxInt8+1)) // out of range
-			},
-			function: MapConfig,
+			input:    &i8,
+			err:      true,
+			env:      map[string]string{"INT8": strconv.Itoa(math.MaxInt8 + 1)}, // out of range
+			function: MapFrom,
 		},
 		{
-			input: &i8,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT8", strconv.Itoa(math.MinInt8-1)) // out of range
-			},
-			function: MustMapConfig,
+			input:    &i8,
+			err:      true,
+			env:      map[string]string{"INT8": strconv.Itoa(math.MinInt8 - 1)}, // out of range
+			function: MustMapFrom,
 		},
 		{
-			input: &i8,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT8", strconv.Itoa(math.MinInt8-1)) // out of range
-			},
-			function: MapConfig,
+			input:    &i8,
+			err:      true,
+			env:      map[string]string{"INT8": strconv.Itoa(math.MinInt8 - 1)}, // out of range
+			function: MapFrom,
 		},
 
 		{
 			input:    &i16,
 			err:      false,
-			function: MapConfig, // 忽略空值
+			function: MapFrom, // 忽略空值
 		},
 		{
 			input:    &i16,
 			err:      true,
-			function: MustMapConfig, // 空值返回错误
+			function: MustMapFrom, // 空值返回错误
 		},
 		{
-			input: &i16,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT16", strconv.Itoa(math.MaxInt16+1)) // out of range
-			},
-			function: MustMapConfig,
+			input:    &i16,
+			err:      true,
+			env:      map[string]string{"INT16": strconv.Itoa(math.MaxInt16 + 1)}, // out of range
+			function: MustMapFrom,
 		},
 		{
-			input: &i16,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT16", strconv.Itoa(math.MaxInt16+1)) // out of range
-			},
-			function: MapConfig,
+			input:    &i16,
+			err:      true,
+			env:      map[string]string{"INT16": strconv.Itoa(math.MaxInt16 + 1)}, // out of range
+			function: MapFrom,
 		},
 		{
-			input: &i16,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT16", strconv.Itoa(math.MinInt16-1)) // out of range
-			},
-			function: MustMapConfig,
+			input:    &i16,
+			err:      true,
+			env:      map[string]string{"INT16": strconv.Itoa(math.MinInt16 - 1)}, // out of range
+			function: MustMapFrom,
 		},
 		{
-			input: &i16,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT16", strconv.Itoa(math.MinInt16-1)) // out of range
-			},
-			function: MapConfig,
+			input:    &i16,
+			err:      true,
+			env:      map[string]string{"INT16": strconv.Itoa(math.MinInt16 - 1)}, // out of range
+			function: MapFrom,
 		},
 
 		{
 			input:    &i32,
 			err:      false,
-			function: MapConfig, // 忽略空值
+			function: MapFrom, // 忽略空值
 		},
 		{
 			input:    &i32,
 			err:      true,
-			function: MustMapConfig, // 空值返回错误
+			function: MustMapFrom, // 空值返回错误
 		},
 		{
-			input: &i32,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT32", strconv.Itoa(math.MaxInt32+1)) // out of range
-			},
-			function: MustMapConfig,
+			input:    &i32,
+			err:      true,
+			env:      map[string]string{"INT32": strconv.Itoa(math.MaxInt32 + 1)}, // out of range
+			function: MustMapFrom,
 		},
 		{
-			input: &i32,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT32", strconv.Itoa(math.MaxInt32+1)) // out of range
-			},
-			function: MapConfig,
+			input:    &i32,
+			err:      true,
+			env:      map[string]string{"INT32": strconv.Itoa(math.MaxInt32 + 1)}, // out of range
+			function: MapFrom,
 		},
 		{
-			input: &i32,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT32", strconv.Itoa(math.MinInt32-1)) // out of range
-			},
-			function: MustMapConfig,
+			input:    &i32,
+			err:      true,
+			env:      map[string]string{"INT32": strconv.Itoa(math.MinInt32 - 1)}, // out of range
+			function: MustMapFrom,
 		},
 		{
-			input: &i32,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT32", strconv.Itoa(math.MinInt32-1)) // out of range
-			},
-			function: MapConfig,
+			input:    &i32,
+			err:      true,
+			env:      map[string]string{"INT32": strconv.Itoa(math.MinInt32 - 1)}, // out of range
+			function: MapFrom,
 		},
 
 		{
 			input:    &i64,
 			err:      false,
-			function: MapConfig, // 忽略空值
+			function: MapFrom, // 忽略空值
 		},
 		{
 			input:    &i64,
 			err:      true,
-			function: MustMapConfig, // 空值返回错误
+			function: MustMapFrom, // 空值返回错误
 		},
 		{
-			input: &i64,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT64", "9223372036854775809") // out of range
-			},
-			function: MustMapConfig,
+			input:    &i64,
+			err:      true,
+			env:      map[string]string{"INT64": "9223372036854775809"}, // out of range
+			function: MustMapFrom,
 		},
 		{
-			input: &i64,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT64", "9223372036854775809") // out of range
-			},
-			function: MapConfig,
+			input:    &i64,
+			err:      true,
+			env:      map[string]string{"INT64": "9223372036854775809"}, // out of range
+			function: MapFrom,
 		},
 		{
-			input: &i64,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT64", "-9223372036854775810") // out of range
-			},
-			function: MustMapConfig,
+			input:    &i64,
+			err:      true,
+			env:      map[string]string{"INT64": "-9223372036854775810"}, // out of range
+			function: MustMapFrom,
 		},
 		{
-			input: &i64,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT64", "-9223372036854775810") // out of range
-			},
-			function: MapConfig,
+			input:    &i64,
+			err:      true,
+			env:      map[string]string{"INT64": "-9223372036854775810"}, // out of range
+			function: MapFrom,
 		},
 	}
 	for i, c := range cases {
-		os.Clearenv()
-		if c.setter != nil {
-			c.setter()
-		}
-		err := c.function(c.input)
+		err := c.function(c.input, c.env)
 		if c.err {
 			if err == nil {
 				t.Fatalf("expect error return, got nil, index: %d", i)
@@ -306,7 +268,7 @@ func TestMapperInteger(t *testing.T) {
 
 // 测试无符号数值类型
 func TestMapperUInteger(t *testing.T) {
-	defer os.Clearenv()
+	t.Parallel()
 	type Uint8 struct {
 		Int8 uint8
 	}
@@ -332,187 +294,151 @@ func TestMapperUInteger(t *testing.T) {
 	var cases = []struct {
 		input    interface{}
 		err      bool
-		setter   func()
-		function func(interface{}) error
+		env      map[string]string
+		function func(interface{}, map[string]string) error
 	}{
 		{
 			input:    &u8,
 			err:      false,
-			function: MapConfig, // 忽略空值
+			function: MapFrom, // 忽略空值
 		},
 		{
 			input:    &u8,
 			err:      true,
-			function: MustMapConfig, // 空值返回错误
+			function: MustMapFrom, // 空值返回错误
 		},
 		{
-			input: &u8,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT8", strconv.Itoa(math.MaxUint8+1)) // out of range
-			},
-			function: MustMapConfig,
+			input:    &u8,
+			err:      true,
+			env:      map[string]string{"INT8": strconv.Itoa(math.MaxUint8 + 1)}, // out of range
+			function: MustMapFrom,
 		},
 		{
-			input: &u8,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT8", strconv.Itoa(math.MaxUint8+1)) // out of range
-			},
-			function: MapConfig,
+			input:    &u8,
+			err:      true,
+			env:      map[string]string{"INT8": strconv.Itoa(math.MaxUint8 + 1)}, // out of range
+			function: MapFrom,
 		},
 		{
-			input: &u8,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT8", "-1") // out of range
-			},
-			function: MustMapConfig,
+			input:    &u8,
+			err:      true,
+			env:      map[string]string{"INT8": "-1"}, // out of range
+			function: MustMapFrom,
 		},
 		{
-			input: &u8,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT8", "-1") // out of range
-			},
-			function: MapConfig,
+			input:    &u8,
+			err:      true,
+			env:      map[string]string{"INT8": "-1"}, // out of range
+			function: MapFrom,
 		},
 
 		{
 			input:    &u16,
 			err:      false,
-			function: MapConfig, // 忽略空值
+			function: MapFrom, // 忽略空值
 		},
 		{
 			input:    &u16,
 			err:      true,
-			function: MustMapConfig, // 空值返回错误
+			function: MustMapFrom, // 空值返回错误
 		},
 		{
-			input: &u16,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT16", strconv.Itoa(math.MaxUint16+1)) // out of range
-			},
-			function: MustMapConfig,
+			input:    &u16,
+			err:      true,
+			env:      map[string]string{"INT16": strconv.Itoa(math.MaxUint16 + 1)}, // out of range
+			function: MustMapFrom,
 		},
 		{
-			input: &u16,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT16", strconv.Itoa(math.MaxUint16+1)) // out of range
-			},
-			function: MapConfig,
+			input:    &u16,
+			err:      true,
+			env:      map[string]string{"INT16": strconv.Itoa(math.MaxUint16 + 1)}, // out of range
+			function: MapFrom,
 		},
 		{
-			input: &u16,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT16", "-1") // out of range
-			},
-			function: MustMapConfig,
+			input:    &u16,
+			err:      true,
+			env:      map[string]string{"INT16": "-1"}, // out of range
+			function: MustMapFrom,
 		},
 		{
-			input: &u16,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT16", "-1") // out of range
-			},
-			function: MapConfig,
+			input:    &u16,
+			err:      true,
+			env:      map[string]string{"INT16": "-1"}, // out of range
+			function: MapFrom,
 		},
 
 		{
 			input:    &u32,
 			err:      false,
-			function: MapConfig, // 忽略空值
+			function: MapFrom, // 忽略空值
 		},
 		{
 			input:    &u32,
 			err:      true,
-			function: MustMapConfig, // 空值返回错误
+			function: MustMapFrom, // 空值返回错误
 		},
 		{
-			input: &u32,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT32", strconv.Itoa(math.MaxUint32+1)) // out of range
-			},
-			function: MustMapConfig,
+			input:    &u32,
+			err:      true,
+			env:      map[string]string{"INT32": strconv.Itoa(math.MaxUint32 + 1)}, // out of range
+			function: MustMapFrom,
 		},
 		{
-			input: &u32,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT32", strconv.Itoa(math.MaxUint32+1)) // out of range
-			},
-			function: MapConfig,
+			input:    &u32,
+			err:      true,
+			env:      map[string]string{"INT32": strconv.Itoa(math.MaxUint32 + 1)}, // out of range
+			function: MapFrom,
 		},
 		{
-			input: &u32,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT32", "-1") // out of range
-			},
-			function: MustMapConfig,
+			input:    &u32,
+			err:      true,
+			env:      map[string]string{"INT32": "-1"}, // out of range
+			function: MustMapFrom,
 		},
 		{
-			input: &u32,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT32", "-1") // out of range
-			},
-			function: MapConfig,
+			input:    &u32,
+			err:      true,
+			env:      map[string]string{"INT32": "-1"}, // out of range
+			function: MapFrom,
 		},
 
 		{
 			input:    &u64,
 			err:      false,
-			function: MapConfig, // 忽略空值
+			function: MapFrom, // 忽略空值
 		},
 		{
 			input:    &u64,
 			err:      true,
-			function: MustMapConfig, // 空值返回错误
+			function: MustMapFrom, // 空值返回错误
 		},
 		{
-			input: &u64,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT64", "18446744073709551617") // out of range
-			},
-			function: MustMapConfig,
+			input:    &u64,
+			err:      true,
+			env:      map[string]string{"INT64": "18446744073709551617"}, // out of range
+			function: MustMapFrom,
 		},
 		{
-			input: &u64,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT64", "18446744073709551617") // out of range
-			},
-			function: MapConfig,
+			input:    &u64,
+			err:      true,
+			env:      map[string]string{"INT64": "18446744073709551617"}, // out of range
+			function: MapFrom,
 		},
 		{
-			input: &u64,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT64", "-1") // out of range
-			},
-			function: MustMapConfig,
+			input:    &u64,
+			err:      true,
+			env:      map[string]string{"INT64": "-1"}, // out of range
+			function: MustMapFrom,
 		},
 		{
-			input: &u64,
-			err:   true,
-			setter: func() {
-				_ = os.Setenv("INT64", "-1") // out of range
-			},
-			function: MapConfig,
+			input:    &u64,
+			err:      true,
+			env:      map[string]string{"INT64": "-1"}, // out of range
+			function: MapFrom,
 		},
 	}
 	for i, c := range cases {
-		os.Clearenv()
-		if c.setter != nil {
-			c.setter()
-		}
-		err := c.function(c.input)
+		err := c.function(c.input, c.env)
 		if c.err {
 			if err == nil {
 				t.Fatalf("expect error return, got nil, index: %d", i)
@@ -532,7 +458,7 @@ func TestMapperBool(t *testing.T) {
 		Bool  bool `env:"BOOL,false"`
 	}
 	var c Config
-	if err := MapConfig(&c); err != nil {
+	if err := MapFrom(&c, nil); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	expect := Config{Debug: true, Bool: false}
@@ -548,7 +474,7 @@ func TestMapperFloat(t *testing.T) {
 		Float32 float32 `env:"Float32,0.123"`
 	}
 	var c Config
-	if err := MapConfig(&c); err != nil {
+	if err := MapFrom(&c, nil); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -566,7 +492,7 @@ func TestMapperSlice(t *testing.T) {
 		Slice []int `env:"SLICE,1,2,3"`
 	}
 	var c Config
-	if err := MapConfig(&c); err != nil {
+	if err := MapFrom(&c, nil); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -582,7 +508,7 @@ func TestMapperArray(t *testing.T) {
 		Slice [3]int `env:"SLICE,1,2,3"`
 	}
 	var c Config
-	if err := MapConfig(&c); err != nil {
+	if err := MapFrom(&c, nil); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -608,7 +534,7 @@ func TestMapperEmbedded(t *testing.T) {
 	}
 
 	var c Config
-	_ = MustMapConfig(&c)
+	_ = MustMapFrom(&c, nil)
 	if c.Struct.Url == "" {
 		t.Fatal("embedded failed")
 	}
@@ -623,6 +549,287 @@ func TestMapperEmbedded(t *testing.T) {
 	}
 }
 
+// 测试匿名结构体展开到父结构体中，和 encoding/json 一样不加前缀
+func TestMapperEmbeddedFlatten(t *testing.T) {
+	type Base struct {
+		Name string `env:"NAME"`
+	}
+	type Mysql struct {
+		Host string `env:"HOST"`
+	}
+	type Config struct {
+		Base
+		*Mysql `env:"DB"`
+		Port   int `env:"PORT"`
+	}
+	source := Map(map[string]string{"APP_NAME": "pkg", "APP_DB_HOST": "db", "APP_PORT": "80"})
+	var c Config
+	if err := (&Loader{Sources: []Source{source}, Prefix: "APP_", AutoPrefix: true}).Load(&c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Name != "pkg" || c.Mysql == nil || c.Host != "db" || c.Port != 80 {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+	d, _ := (&Loader{Prefix: "APP_", AutoPrefix: true}).Describe(&c)
+	if d[0].Key != "APP_NAME" || d[0].Path != "Config.Base.Name" || d[1].Key != "APP_DB_HOST" {
+		t.Fatalf("unexpected description: %+v", d)
+	}
+}
+
+// 测试嵌套结构体的前缀
+func TestMapperPrefix(t *testing.T) {
+	type DB struct {
+		Host string `env:"HOST,localhost"`
+		Port int    `env:"PORT,3306"`
+	}
+	type Config struct {
+		Name    string
+		Primary DB
+		Replica *DB
+		Backup  DB `prefix:"BAK_"`
+		Shared  DB `prefix:""`
+	}
+	source := Map(map[string]string{
+		"APP_NAME":         "pkg",
+		"APP_PRIMARY_HOST": "primary",
+		"APP_REPLICA_HOST": "replica",
+		"APP_REPLICA_PORT": "3307",
+		"APP_BAK_HOST":     "backup",
+		"APP_HOST":         "shared",
+	})
+
+	var c Config
+	loader := &Loader{Sources: []Source{source}, Prefix: "APP_", AutoPrefix: true}
+	if err := loader.Load(&c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := Config{
+		Name:    "pkg",
+		Primary: DB{Host: "primary", Port: 3306},
+		Replica: &DB{Host: "replica", Port: 3307},
+		Backup:  DB{Host: "backup", Port: 3306},
+		Shared:  DB{Host: "shared", Port: 3306},
+	}
+	if !reflect.DeepEqual(c, expect) {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+
+	// 未开启 AutoPrefix 时只有 prefix 标签生效
+	var c1 Config
+	loader.AutoPrefix = false
+	if err := loader.Load(&c1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c1.Primary.Host != "shared" || c1.Backup.Host != "backup" {
+		t.Fatalf("unexpected value: %+v", c1)
+	}
+
+	// 错误中的 Key 包含前缀
+	err := (&Loader{Sources: []Source{source}, Prefix: "APP_", AutoPrefix: true, Strict: true}).Load(&struct {
+		Primary struct {
+			User string
+		}
+	}{})
+	var e ConfigError
+	if !errors.As(err, &e) || e.Key != "APP_PRIMARY_USER" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// 测试 map
+func TestMapperMap(t *testing.T) {
+	type Table map[string]interface{}
+	type Config struct {
+		Labels  map[string]string `env:"LABELS,app=pkg, env = dev"`
+		Weights map[string]int    `env:"WEIGHTS,a=1,b=2"`
+		Ports   map[int]bool      `env:"PORTS,80=true,443=false"`
+		Args    Table             `env:"ARGS,x-max-priority=10"`
+	}
+	var c Config
+	if err := MustMapFrom(&c, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := Config{
+		Labels:  map[string]string{"app": "pkg", "env": "dev"},
+		Weights: map[string]int{"a": 1, "b": 2},
+		Ports:   map[int]bool{80: true, 443: false},
+		Args:    Table{"x-max-priority": "10"},
+	}
+	if !reflect.DeepEqual(c, expect) {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+
+	var cases = []interface{}{
+		&struct {
+			M map[string]string `env:"M,a"`
+		}{},
+		&struct {
+			M map[string]int `env:"M,a=b"`
+		}{},
+		&struct {
+			M map[string]fmt.Stringer `env:"M,a=b"`
+		}{},
+		// 结构体、切片等类型的值需要使用 format:"json"
+		&struct {
+			M map[string]struct{ Host string } `env:"M,a=b"`
+		}{},
+		&struct {
+			M map[string][]string `env:"M,a=b"`
+		}{},
+	}
+	for i, c := range cases {
+		if err := MapFrom(c, map[string]string{"HOST": "localhost"}); err == nil {
+			t.Fatalf("expect error returned, index: %d", i)
+		}
+	}
+}
+
+// 测试结构体切片
+func TestMapperStructSlice(t *testing.T) {
+	type Server struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT,9200"`
+	}
+	type Config struct {
+		Servers []Server
+		Queues  []*struct {
+			Name string `env:"NAME"`
+		}
+		Empty []Server
+	}
+	source := Map(map[string]string{
+		"SERVERS_0_HOST": "es-0",
+		"SERVERS_1_HOST": "es-1",
+		"SERVERS_1_PORT": "9300",
+		"QUEUES_0_NAME":  "billing",
+		"QUEUES_X_NAME":  "ignored",
+	})
+	var c Config
+	if err := Load(&c, source); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(c.Servers, []Server{{"es-0", 9200}, {"es-1", 9300}}) {
+		t.Fatalf("unexpected servers: %+v", c.Servers)
+	}
+	if len(c.Queues) != 1 || c.Queues[0].Name != "billing" {
+		t.Fatalf("unexpected queues: %+v", c.Queues)
+	}
+	if c.Empty != nil {
+		t.Fatalf("unexpected empty: %+v", c.Empty)
+	}
+
+	// strict 模式下的错误带有下标
+	err := MustLoad(&c, source)
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Key != "EMPTY" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = MustLoad(&c, Map(map[string]string{"SERVERS_1_HOST": "es-1", "QUEUES_0_NAME": "q", "EMPTY_0_HOST": "h"}))
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Path != "Config.Servers[0].Host" || errs[0].Key != "SERVERS_0_HOST" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// 测试扩展的标签语法
+func TestMapperTagOptions(t *testing.T) {
+	type Config struct {
+		Hosts    []string          `env:"HOSTS" default:"a,b"`
+		Paths    []string          `env:"PATHS" default:"/a;/b" sep:";"`
+		Ports    [2]int            `env:"PORTS" default:"1 2" sep:" "`
+		Labels   map[string]string `env:"LABELS" default:"a=1;b=2" sep:";"`
+		Prompt   string            `env:"PROMPT" default:"> " trim:"false"`
+		Name     string            `default:"pkg"`
+		Optional string            `required:"false"`
+	}
+	var c Config
+	if err := MustMapFrom(&c, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := Config{
+		Hosts:  []string{"a", "b"},
+		Paths:  []string{"/a", "/b"},
+		Ports:  [2]int{1, 2},
+		Labels: map[string]string{"a": "1", "b": "2"},
+		Prompt: "> ",
+		Name:   "pkg",
+	}
+	if !reflect.DeepEqual(c, expect) {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+
+	// required 标签优先于 MapConfig
+	type Required struct {
+		Name string `env:"NAME" required:"true"`
+	}
+	var r Required
+	var e ConfigError
+	if err := MapFrom(&r, nil); !errors.As(err, &e) || e.Key != "NAME" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type Invalid struct {
+		Name string `required:"yes"`
+	}
+	var i Invalid
+	if err := MapFrom(&i, nil); err == nil {
+		t.Fatalf("expect error returned")
+	}
+
+	// env 标签中的默认值和 default 标签不能同时使用
+	type Conflict struct {
+		Name string `env:"NAME,old" default:"new"`
+	}
+	var conflict Conflict
+	if err := MapFrom(&conflict, map[string]string{"NAME": "v"}); err == nil || !strings.Contains(err.Error(), "default tag") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// 测试区分没有设置和设置为空的 key
+func TestMapperAllowEmpty(t *testing.T) {
+	type Config struct {
+		RoutingKey string `env:"ROUTING_KEY,orders" allowEmpty:"true"`
+		Queue      string `env:"QUEUE,default"`
+		Count      int    `env:"COUNT,10"`
+	}
+	source := Map(map[string]string{"ROUTING_KEY": "", "QUEUE": "", "COUNT": ""})
+
+	// 默认情况下，设置为空的 key 和没有设置一样使用默认值，allowEmpty 标签除外
+	c := Config{Count: 1}
+	if err := Load(&c, source); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c != (Config{RoutingKey: "", Queue: "default", Count: 10}) {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+
+	// Loader.AllowEmpty 对所有字段生效，空值覆盖字段原有的值
+	c = Config{Count: 1}
+	if err := (&Loader{Sources: []Source{source}, AllowEmpty: true}).Load(&c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c != (Config{}) {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+
+	// strict 模式下区分 missing 和 empty
+	type Strict struct {
+		Missing string `env:"MISSING"`
+		Empty   string `env:"EMPTY"`
+		Allowed string `env:"ALLOWED" allowEmpty:"true"`
+	}
+	var s Strict
+	err := MustLoad(&s, Map(map[string]string{"EMPTY": " ", "ALLOWED": ""}))
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if errs[0].Key != "MISSING" || errs[0].Msg != "missing value" ||
+		errs[1].Key != "EMPTY" || errs[1].Msg != "empty value" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 // **************************** Benchmark ****************************
 
 func BenchmarkMustMapConfig(b *testing.B) {
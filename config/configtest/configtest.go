@@ -0,0 +1,30 @@
+// Package configtest 提供测试 config 相关代码时使用的辅助方法
+//
+// 不需要读取真实环境变量的测试应该优先使用 config.MapFrom 或 config.Load(&c, config.Map(values))，
+// 它们不会修改当前进程的环境变量，可以和 t.Parallel() 一起使用
+package configtest
+
+import (
+	"os"
+	"testing"
+)
+
+// Setenv 设置 kv 中的环境变量，测试结束时恢复为原来的值，原来不存在的环境变量会被删除
+// 环境变量是整个进程共享的，调用了 Setenv 的测试不能使用 t.Parallel()
+func Setenv(t testing.TB, kv map[string]string) {
+	t.Helper()
+	for key, value := range kv {
+		old, ok := os.LookupEnv(key)
+		if err := os.Setenv(key, value); err != nil {
+			t.Fatalf("configtest: set %s: %v", key, err)
+		}
+		key := key
+		t.Cleanup(func() {
+			if ok {
+				_ = os.Setenv(key, old)
+			} else {
+				_ = os.Unsetenv(key)
+			}
+		})
+	}
+}
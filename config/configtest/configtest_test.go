@@ -0,0 +1,29 @@
+package configtest
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSetenv(t *testing.T) {
+	const existing, missing = "CONFIGTEST_EXISTING", "CONFIGTEST_MISSING"
+	if err := os.Setenv(existing, "old"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Unsetenv(existing)
+
+	t.Run("set", func(t *testing.T) {
+		Setenv(t, map[string]string{existing: "new", missing: "value"})
+		if os.Getenv(existing) != "new" || os.Getenv(missing) != "value" {
+			t.Fatalf("environment should be set")
+		}
+	})
+
+	// 子测试结束之后恢复
+	if os.Getenv(existing) != "old" {
+		t.Fatalf("unexpected value: %s", os.Getenv(existing))
+	}
+	if _, ok := os.LookupEnv(missing); ok {
+		t.Fatalf("%s should be unset", missing)
+	}
+}
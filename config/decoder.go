@@ -0,0 +1,87 @@
+package config
+
+import (
+	"encoding"
+	"reflect"
+	"sync"
+)
+
+// DecodeFunc 把环境变量中的字符串解析为某个类型的值
+// 返回值的类型需要可以赋值或转换为注册时指定的类型
+type DecodeFunc func(value string) (interface{}, error)
+
+// decoders 用户注册的自定义解析方法，优先级高于内置的类型处理
+var decoders = struct {
+	sync.RWMutex
+	m map[reflect.Type]DecodeFunc
+}{m: make(map[reflect.Type]DecodeFunc)}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// RegisterDecoder 为类型 t 注册一个自定义的解析方法，重复注册会覆盖之前的方法
+// 常用于自定义枚举、第三方库中的类型，例如：
+//
+//	config.RegisterDecoder(reflect.TypeOf(zerolog.Level(0)), func(s string) (interface{}, error) {
+//		return zerolog.ParseLevel(s)
+//	})
+//
+// 如果 decoder 为 nil，则删除 t 已注册的方法
+func RegisterDecoder(t reflect.Type, decoder DecodeFunc) {
+	decoders.Lock()
+	defer decoders.Unlock()
+	if decoder == nil {
+		delete(decoders.m, t)
+		return
+	}
+	decoders.m[t] = decoder
+}
+
+// getDecoder 返回 t 注册的解析方法
+func getDecoder(t reflect.Type) (DecodeFunc, bool) {
+	decoders.RLock()
+	defer decoders.RUnlock()
+	decoder, ok := decoders.m[t]
+	return decoder, ok
+}
+
+// isTextUnmarshaler 判断 t 或者 *t 是否实现了 encoding.TextUnmarshaler
+func isTextUnmarshaler(t reflect.Type) bool {
+	return t.Implements(textUnmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// setTextUnmarshaler 处理实现了 encoding.TextUnmarshaler 的类型，
+// 如果 v 没有实现这个接口，handled 返回 false
+func (m *mapper) setTextUnmarshaler(v reflect.Value, value string) (handled bool, err error) {
+	// 指针类型交给 setPtr 处理，创建出指向的值之后再判断
+	if v.Kind() == reflect.Ptr || !v.CanAddr() {
+		return false, nil
+	}
+	u, ok := v.Addr().Interface().(encoding.TextUnmarshaler)
+	if !ok {
+		return false, nil
+	}
+	if err := u.UnmarshalText([]byte(value)); err != nil {
+		return true, wrapE("setTextUnmarshaler", err)
+	}
+	return true, nil
+}
+
+// setDecoded 使用注册的 DecodeFunc 设置值
+func (m *mapper) setDecoded(v reflect.Value, value string, decoder DecodeFunc) error {
+	i, err := decoder(value)
+	if err != nil {
+		return wrapE("setDecoded", err)
+	}
+	rv := reflect.ValueOf(i)
+	switch {
+	case !rv.IsValid():
+		v.Set(reflect.Zero(v.Type()))
+	case rv.Type().AssignableTo(v.Type()):
+		v.Set(rv)
+	case rv.Type().ConvertibleTo(v.Type()):
+		v.Set(rv.Convert(v.Type()))
+	default:
+		return newE("setDecoded: cannot use %s as %s", rv.Type(), v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+type color int
+
+const (
+	red color = iota + 1
+	green
+)
+
+// point 实现了 encoding.TextUnmarshaler，格式为 "x:y"
+type point struct {
+	X, Y string
+}
+
+func (p *point) UnmarshalText(text []byte) error {
+	parts := strings.Split(string(text), ":")
+	if len(parts) != 2 {
+		return errors.New("invalid point")
+	}
+	p.X, p.Y = parts[0], parts[1]
+	return nil
+}
+
+// 测试 encoding.TextUnmarshaler
+func TestMapperTextUnmarshaler(t *testing.T) {
+	type Config struct {
+		Point  point   `env:"POINT,1:2"`
+		Ptr    *point  `env:"PTR,3:4"`
+		Points []point `env:"POINTS,5:6,7:8"`
+	}
+	var c Config
+	if err := MustMapFrom(&c, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := Config{
+		Point:  point{X: "1", Y: "2"},
+		Ptr:    &point{X: "3", Y: "4"},
+		Points: []point{{X: "5", Y: "6"}, {X: "7", Y: "8"}},
+	}
+	if !reflect.DeepEqual(c, expect) {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+
+	type Invalid struct {
+		Point point `env:"POINT,1"`
+	}
+	var i Invalid
+	if err := MapFrom(&i, nil); err == nil {
+		t.Fatalf("expect error returned")
+	}
+}
+
+// 测试 RegisterDecoder
+func TestRegisterDecoder(t *testing.T) {
+	RegisterDecoder(reflect.TypeOf(zerolog.Level(0)), func(s string) (interface{}, error) {
+		return zerolog.ParseLevel(s)
+	})
+	RegisterDecoder(reflect.TypeOf(color(0)), func(s string) (interface{}, error) {
+		switch s {
+		case "red":
+			return 1, nil // int 可以转换为 color
+		case "green":
+			return green, nil
+		}
+		return nil, errors.New("unknown color")
+	})
+	defer RegisterDecoder(reflect.TypeOf(zerolog.Level(0)), nil)
+	defer RegisterDecoder(reflect.TypeOf(color(0)), nil)
+
+	type Config struct {
+		Level  zerolog.Level `env:"LEVEL,warn"`
+		Color  color         `env:"COLOR,red"`
+		Colors []color       `env:"COLORS,green,red"`
+	}
+	var c Config
+	if err := MustMapFrom(&c, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := Config{Level: zerolog.WarnLevel, Color: red, Colors: []color{green, red}}
+	if !reflect.DeepEqual(c, expect) {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+
+	type Invalid struct {
+		Color color `env:"COLOR,blue"`
+	}
+	var i Invalid
+	if err := MapFrom(&i, nil); err == nil {
+		t.Fatalf("expect error returned")
+	}
+
+	// 删除之后按 Kind 处理
+	RegisterDecoder(reflect.TypeOf(color(0)), nil)
+	if err := MapFrom(&i, nil); err == nil {
+		t.Fatalf("expect error returned")
+	}
+	type Plain struct {
+		Color color `env:"COLOR,2"`
+	}
+	var p Plain
+	if err := MapFrom(&p, nil); err != nil || p.Color != green {
+		t.Fatalf("unexpected result: %v, %v", p.Color, err)
+	}
+}
@@ -0,0 +1,248 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const tagDesc = "desc"
+
+// Field 一个配置项的描述
+type Field struct {
+	Key      string `json:"key"`      // 环境变量名，包含前缀
+	Path     string `json:"path"`     // 字段在结构体中的路径，如 Config.DB.Port
+	Type     string `json:"type"`     // 字段的 Go 类型
+	Default  string `json:"default"`  // 默认值
+	Required bool   `json:"required"` // 是否不允许为空
+	Secret   bool   `json:"secret"`   // 是否是敏感字段
+	Desc     string `json:"desc"`     // desc 标签的内容
+
+	typ    reflect.Type
+	sep    string
+	format string
+}
+
+// Description 一个配置结构体中所有配置项的描述，可以输出为 Markdown、.env.example 和 JSON Schema
+type Description []Field
+
+// Describe 返回 dest 中所有配置项的描述，dest 可以是结构体或者结构体指针，
+// 遍历的规则和 MapConfig 相同，结构体切片的 key 以下标 0 为例，如 SERVERS_0_HOST
+func Describe(dest interface{}) (Description, error) {
+	return newMapper(false).describe(dest)
+}
+
+// Describe 同 Describe，但是会使用 l 的 Strict、Prefix、AutoPrefix 设置
+func (l *Loader) Describe(dest interface{}) (Description, error) {
+	return l.newMapper().describe(dest)
+}
+
+func (m *mapper) describe(dest interface{}) (Description, error) {
+	if dest == nil {
+		return nil, ErrorNilInput
+	}
+	t := indirectType(reflect.TypeOf(dest))
+	if t.Kind() != reflect.Struct {
+		return nil, ErrorNonStruct
+	}
+	var d Description
+	m.describeStruct(t, t.Name(), m.prefix, &d)
+	return d, nil
+}
+
+// describeStruct 和 mapStruct 一样遍历结构体的字段，但是不读取值
+func (m *mapper) describeStruct(t reflect.Type, path, prefix string, d *Description) {
+	for _, data := range fields(t) {
+		ft := data.typ
+		data.path = joinPath(path, ft.Name)
+		data.prefix = prefix + m.nestedPrefix(data)
+		data.addPrefix(prefix)
+		data.addKindSuffix()
+
+		ftyp := indirectType(ft.Type)
+		switch {
+		case data.isJSON():
+			// format:"json" 的字段作为一个整体
+		case isStructSlice(ft.Type):
+			m.describeStruct(indirectType(ft.Type.Elem()), data.path+"[0]", data.key+"_0_", d)
+			continue
+		case isStruct(ftyp):
+			m.describeStruct(ftyp, data.path, data.prefix, d)
+			continue
+		}
+		*d = append(*d, Field{
+			Key:      data.key,
+			Path:     data.path,
+			Type:     ft.Type.String(),
+			Default:  m.defaultValue(data),
+			Required: m.isRequired(data),
+			Secret:   data.secret,
+			Desc:     ft.Tag.Get(tagDesc),
+			typ:      ftyp,
+			sep:      data.separator(),
+			format:   data.format,
+		})
+		if hasFactory(ft.Type) {
+			m.describeImpls(ft.Type, data, d)
+		}
+	}
+}
+
+// Markdown 输出为 Markdown 表格
+func (d Description) Markdown() string {
+	var b strings.Builder
+	b.WriteString("| Key | Type | Default | Required | Secret | Description |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, f := range d {
+		_, _ = fmt.Fprintf(&b, "| `%s` | `%s` | %s | %s | %s | %s |\n",
+			f.Key, f.Type, markdownCode(f.Default), yesNo(f.Required), yesNo(f.Secret), markdownEscape(f.Desc))
+	}
+	return b.String()
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownEscape(s) + "`"
+}
+
+func markdownEscape(s string) string {
+	return strings.Replace(s, "|", `\|`, -1)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// DotEnv 输出为 .env.example 的格式，每个配置项之前以注释的形式写上描述、类型和是否必填
+// 敏感字段不会写出默认值
+func (d Description) DotEnv() string {
+	var b strings.Builder
+	for i, f := range d {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if f.Desc != "" {
+			_, _ = fmt.Fprintf(&b, "# %s\n", f.Desc)
+		}
+		attrs := []string{f.Type}
+		if f.Required {
+			attrs = append(attrs, "required")
+		}
+		if f.Secret {
+			attrs = append(attrs, "secret, can also be read from "+f.Key+fileSuffix)
+		}
+		_, _ = fmt.Fprintf(&b, "# %s\n", strings.Join(attrs, ", "))
+		val := f.Default
+		if f.Secret {
+			val = ""
+		}
+		_, _ = fmt.Fprintf(&b, "%s=%s\n", f.Key, val)
+	}
+	return b.String()
+}
+
+// JSONSchema 输出为 JSON Schema（draft-07），每个配置项是一个 property
+func (d Description) JSONSchema() ([]byte, error) {
+	properties := make(map[string]interface{}, len(d))
+	required := make([]string, 0)
+	for _, f := range d {
+		// bytes、literal、percent 格式的数字写作字符串，如 64MiB、0x1F、50%
+		formatted := f.format != "" && f.format != formatJSON
+		property := jsonSchemaType(f.typ, formatted)
+		if f.format == formatJSON && f.typ.Kind() == reflect.Struct {
+			property = map[string]interface{}{"type": "object"}
+		}
+		if f.Desc != "" {
+			property["description"] = f.Desc
+		}
+		if f.Default != "" && !f.Secret {
+			property["default"] = jsonSchemaValue(f.typ, f.Default, f.sep, formatted)
+			var v interface{}
+			if f.format == formatJSON && json.Unmarshal([]byte(f.Default), &v) == nil {
+				property["default"] = v
+			}
+		}
+		if f.Secret {
+			property["writeOnly"] = true
+		}
+		properties[f.Key] = property
+		if f.Required {
+			required = append(required, f.Key)
+		}
+	}
+	return json.MarshalIndent(map[string]interface{}{
+		"$schema":    "http://json-schema.org/draft-07/schema#",
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}, "", "  ")
+}
+
+// jsonSchemaType 返回 t 对应的 JSON Schema 类型，formatted 为 true 时数字类型为 string
+func jsonSchemaType(t reflect.Type, formatted bool) map[string]interface{} {
+	if t == nil {
+		return map[string]interface{}{"type": "string"}
+	}
+	if isStdType(t) || isTextUnmarshaler(t) {
+		return map[string]interface{}{"type": "string"}
+	}
+	if _, ok := getDecoder(t); ok {
+		return map[string]interface{}{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !formatted {
+			return map[string]interface{}{"type": "integer"}
+		}
+	case reflect.Float32, reflect.Float64:
+		if !formatted {
+			return map[string]interface{}{"type": "number"}
+		}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": jsonSchemaType(indirectType(t.Elem()), formatted)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": jsonSchemaType(indirectType(t.Elem()), formatted)}
+	}
+	return map[string]interface{}{"type": "string"}
+}
+
+// jsonSchemaValue 把默认值转为 JSON Schema 中对应的类型，转换失败时保留字符串，
+// formatted 为 true 时数字保留为字符串，和 jsonSchemaType 一致
+func jsonSchemaValue(t reflect.Type, value, sep string, formatted bool) interface{} {
+	if isStdType(t) || isTextUnmarshaler(t) {
+		return value
+	}
+	if _, ok := getDecoder(t); ok {
+		return value
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(value, 64); err == nil && !formatted {
+			return json.Number(value)
+		}
+	case reflect.Slice, reflect.Array:
+		items := strings.Split(value, sep)
+		result := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			result = append(result, jsonSchemaValue(indirectType(t.Elem()), strings.TrimSpace(item), sep, formatted))
+		}
+		return result
+	}
+	return value
+}
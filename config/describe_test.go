@@ -0,0 +1,162 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type describeConfig struct {
+	Name    string        `env:"NAME" default:"pkg" desc:"service name"`
+	Port    int           `env:"PORT,8080" desc:"listen port" required:"true"`
+	Debug   bool          `env:"DEBUG,false"`
+	Timeout time.Duration `env:"TIMEOUT,5s"`
+	Hosts   []string      `env:"HOSTS" default:"a;b" sep:";"`
+	Token   Secret        `env:"TOKEN,t0ken" desc:"api | token"`
+	Primary struct {
+		Host string `env:"HOST"`
+	}
+	Servers []struct {
+		Addr string `env:"ADDR"`
+	}
+	Ignored string `env:"-"`
+}
+
+func TestDescribe(t *testing.T) {
+	d, err := (&Loader{Prefix: "APP_", AutoPrefix: true}).Describe(&describeConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keys := make([]string, 0, len(d))
+	for _, f := range d {
+		keys = append(keys, f.Key)
+	}
+	expect := []string{"APP_NAME", "APP_PORT", "APP_DEBUG", "APP_TIMEOUT", "APP_HOSTS", "APP_TOKEN",
+		"APP_PRIMARY_HOST", "APP_SERVERS_0_ADDR"}
+	if !reflect.DeepEqual(keys, expect) {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+	port := d[1]
+	if port.Path != "describeConfig.Port" || port.Type != "int" || port.Default != "8080" ||
+		!port.Required || port.Secret || port.Desc != "listen port" {
+		t.Fatalf("unexpected field: %+v", port)
+	}
+	if !d[5].Secret || d[0].Required {
+		t.Fatalf("unexpected fields: %+v", d)
+	}
+	if d[7].Path != "describeConfig.Servers[0].Addr" {
+		t.Fatalf("unexpected path: %s", d[7].Path)
+	}
+
+	// 非 strict 模式下只有 required 标签生效，strict 模式下所有字段都是必填
+	d, _ = Describe(describeConfig{})
+	if d[0].Key != "NAME" || d[0].Required || !d[1].Required {
+		t.Fatalf("unexpected fields: %+v", d)
+	}
+	d, _ = (&Loader{Strict: true}).Describe(describeConfig{})
+	if !d[0].Required {
+		t.Fatalf("unexpected fields: %+v", d)
+	}
+
+	if _, err := Describe(nil); err == nil {
+		t.Fatalf("expect error returned")
+	}
+	if _, err := Describe("string"); err == nil {
+		t.Fatalf("expect error returned")
+	}
+}
+
+func TestDescription_Markdown(t *testing.T) {
+	d, _ := Describe(&describeConfig{})
+	md := d.Markdown()
+	for _, line := range []string{
+		"| Key | Type | Default | Required | Secret | Description |",
+		"| `PORT` | `int` | `8080` | yes | no | listen port |",
+		"| `TOKEN` | `config.Secret` | `t0ken` | no | yes | api \\| token |",
+		"| `HOST` | `string` |  | no | no |  |",
+	} {
+		if !strings.Contains(md, line+"\n") {
+			t.Fatalf("expect %q in markdown:\n%s", line, md)
+		}
+	}
+}
+
+func TestDescription_DotEnv(t *testing.T) {
+	d, _ := Describe(&describeConfig{})
+	env := d.DotEnv()
+	for _, block := range []string{
+		"# service name\n# string\nNAME=pkg\n",
+		"# listen port\n# int, required\nPORT=8080\n",
+		"# api | token\n# config.Secret, secret, can also be read from TOKEN_FILE\nTOKEN=\n",
+	} {
+		if !strings.Contains(env, block) {
+			t.Fatalf("expect %q in dotenv:\n%s", block, env)
+		}
+	}
+	// 生成的 .env 文件可以被 DotEnvFile 解析
+	if _, err := DotEnvFile(writeFile(t, ".env.example", env)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDescription_JSONSchema(t *testing.T) {
+	d, _ := (&Loader{Strict: true}).Describe(&describeConfig{})
+	b, err := d.JSONSchema()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var schema struct {
+		Properties map[string]map[string]interface{} `json:"properties"`
+		Required   []string                          `json:"required"`
+	}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := map[string]map[string]interface{}{
+		"PORT":    {"type": "integer", "default": float64(8080), "description": "listen port"},
+		"DEBUG":   {"type": "boolean", "default": false},
+		"TIMEOUT": {"type": "string", "default": "5s"},
+		"HOSTS":   {"type": "array", "items": map[string]interface{}{"type": "string"}, "default": []interface{}{"a", "b"}},
+		"TOKEN":   {"type": "string", "description": "api | token", "writeOnly": true},
+	}
+	for key, property := range expect {
+		if !reflect.DeepEqual(schema.Properties[key], property) {
+			t.Fatalf("unexpected property %s: %v", key, schema.Properties[key])
+		}
+	}
+	if len(schema.Required) != len(d) {
+		t.Fatalf("unexpected required: %v", schema.Required)
+	}
+}
+
+// bytes、literal、percent 格式的字段在 JSON Schema 中是字符串
+func TestDescription_JSONSchemaFormat(t *testing.T) {
+	type Config struct {
+		Size  int64    `env:"SIZE,64MiB" format:"bytes"`
+		Mode  uint32   `env:"MODE,0x1F" format:"literal"`
+		Ratio float64  `env:"RATIO,50%" format:"percent"`
+		Sizes []uint64 `env:"SIZES" default:"1KiB;2KiB" sep:";" format:"bytes"`
+	}
+	d, _ := Describe(&Config{})
+	b, err := d.JSONSchema()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var schema struct {
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := map[string]map[string]interface{}{
+		"SIZE":  {"type": "string", "default": "64MiB"},
+		"MODE":  {"type": "string", "default": "0x1F"},
+		"RATIO": {"type": "string", "default": "50%"},
+		"SIZES": {"type": "array", "items": map[string]interface{}{"type": "string"}, "default": []interface{}{"1KiB", "2KiB"}},
+	}
+	if !reflect.DeepEqual(schema.Properties, expect) {
+		t.Fatalf("unexpected properties: %v", schema.Properties)
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Change 一个配置项的变化，敏感字段的值已经被替换为 ******
+// 新增的配置项 Old 为空，删除的配置项 New 为空
+type Change struct {
+	Key    string
+	Old    string
+	New    string
+	Secret bool
+}
+
+// String fmt.Stringer，如 DB_HOST: "a" -> "b"
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %q -> %q", c.Key, c.Old, c.New)
+}
+
+// Changes 两个配置之间所有的变化
+type Changes []Change
+
+// String fmt.Stringer，每行一个变化
+func (cs Changes) String() string {
+	lines := make([]string, 0, len(cs))
+	for _, c := range cs {
+		lines = append(lines, c.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Diff 比较两个配置，返回值不同的配置项，old 和 new 的要求同 Export，通常是同一个类型
+// 比较的是 Export 导出的字符串，按 new 中的顺序排列，old 中有而 new 中没有的配置项排在最后
+// 敏感字段的值只会显示为 ******，但是值的变化仍然会被检测到，常用于 Watcher.OnChange：
+//
+//	w.OnChange(func(old, new interface{}) {
+//		changes, _ := config.Diff(old, new)
+//		log.Println(changes)
+//	})
+func Diff(old, new interface{}) (Changes, error) {
+	return newMapper(false).diff(old, new)
+}
+
+// Diff 同 Diff，但是会使用 l 的 Prefix、AutoPrefix 设置
+func (l *Loader) Diff(old, new interface{}) (Changes, error) {
+	return l.newMapper().diff(old, new)
+}
+
+func (m *mapper) diff(old, new interface{}) (Changes, error) {
+	before, err := m.export(old)
+	if err != nil {
+		return nil, err
+	}
+	after, err := m.export(new)
+	if err != nil {
+		return nil, err
+	}
+	olds := make(map[string]Var, len(before))
+	for _, v := range before {
+		olds[v.Key] = v
+	}
+	var changes Changes
+	for _, v := range after {
+		o, ok := olds[v.Key]
+		delete(olds, v.Key)
+		if ok && o.Value == v.Value {
+			continue
+		}
+		changes = append(changes, Change{Key: v.Key, Old: o.redacted(), New: v.redacted(), Secret: v.Secret || o.Secret})
+	}
+	for _, o := range before {
+		if _, ok := olds[o.Key]; ok {
+			changes = append(changes, Change{Key: o.Key, Old: o.redacted(), Secret: o.Secret})
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type Config struct {
+		Host     string   `env:"HOST"`
+		Port     int      `env:"PORT"`
+		Password Secret   `env:"PASSWORD"`
+		Token    string   `env:"TOKEN" secret:"true"`
+		Hosts    []string `env:"HOSTS"`
+		Replica  *struct {
+			Host string `env:"HOST"`
+		}
+	}
+	old := Config{Host: "a", Port: 80, Password: "old", Token: "same", Hosts: []string{"a"}}
+	new := old
+	new.Port = 8080
+	new.Password = "new"
+	new.Hosts = []string{"a", "b"}
+	new.Replica = &struct {
+		Host string `env:"HOST"`
+	}{Host: "replica"}
+
+	changes, err := (&Loader{AutoPrefix: true}).Diff(&old, new)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := Changes{
+		{Key: "PORT", Old: "80", New: "8080"},
+		{Key: "PASSWORD", Old: mask, New: mask, Secret: true},
+		{Key: "HOSTS", Old: "a", New: "a,b"},
+		{Key: "REPLICA_HOST", Old: "", New: "replica"},
+	}
+	if !reflect.DeepEqual(changes, expect) {
+		t.Fatalf("unexpected changes: %v", changes)
+	}
+	if changes[0].String() != `PORT: "80" -> "8080"` {
+		t.Fatalf("unexpected string: %s", changes[0])
+	}
+
+	// 删除的配置项排在最后
+	changes, _ = (&Loader{AutoPrefix: true}).Diff(new, old)
+	if last := changes[len(changes)-1]; last.Key != "REPLICA_HOST" || last.Old != "replica" || last.New != "" {
+		t.Fatalf("unexpected changes: %v", changes)
+	}
+
+	if changes, _ := Diff(old, old); len(changes) != 0 {
+		t.Fatalf("unexpected changes: %v", changes)
+	}
+	if _, err := Diff(nil, old); err == nil {
+		t.Fatalf("expect error returned")
+	}
+}
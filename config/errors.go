@@ -2,29 +2,117 @@ package config
 
 import (
 	"fmt"
+	"strings"
+
 	"github.com/pkg/errors"
 )
 
 // ConfigError mapper 过程中会出现的错误
+// 字段相关的错误会带上 Path、Key、Value，方便定位是哪一个配置出了问题
 type ConfigError struct {
-	msg string
-	err error
+	Path  string // 字段在结构体中的路径，如 Config.DB.Port
+	Key   string // 字段对应的环境变量名
+	Value string // 从环境变量读到的原始值
+	Msg   string // 错误原因
+	Err   error  // 引起这个错误的底层错误，可以通过 errors.Unwrap 获取
 }
 
 // Error error interface
 func (c ConfigError) Error() string {
-	if c.err != nil {
-		return errors.Wrap(c.err, c.msg).Error()
+	msg := c.Msg
+	if c.Err != nil {
+		msg = errors.Wrap(c.Err, c.Msg).Error()
+	}
+	switch {
+	case c.Path == "" && c.Key == "":
+		return msg
+	case c.Key == "":
+		return fmt.Sprintf("%s: %s", c.Path, msg)
+	case c.Path == "":
+		// 和字段无关的 key，如 Loader.DisallowUnknown 找到的未知 key，不输出值，避免泄露敏感信息
+		return fmt.Sprintf("%s: %s", c.Key, msg)
 	}
-	return c.msg
+	return fmt.Sprintf("%s (%s=%q): %s", c.Path, c.Key, c.Value, msg)
+}
+
+// Unwrap 返回底层错误，支持 errors.Is 和 errors.As
+func (c ConfigError) Unwrap() error {
+	return c.Err
 }
 
 func newE(msg string, args ...interface{}) ConfigError {
-	return ConfigError{msg: fmt.Sprintf(msg, args...)}
+	return ConfigError{Msg: fmt.Sprintf(msg, args...)}
 }
 
 func wrapE(msg string, err error) ConfigError {
-	return ConfigError{msg: msg, err: err}
+	return ConfigError{Msg: msg, Err: err}
+}
+
+// fieldE 生成一个字段相关的错误，如果 err 本身就是 ConfigError，则取出其中的 Msg 和 Err
+// 敏感字段的值会被替换为 ******，包括底层错误信息中的值
+func fieldE(d *data, err error) ConfigError {
+	e := ConfigError{Path: d.path, Key: d.key, Value: d.val}
+	if c, ok := err.(ConfigError); ok {
+		e.Msg, e.Err = c.Msg, c.Err
+	} else {
+		e.Err = err
+	}
+	if d.secret && d.val != "" {
+		values := d.secretValues()
+		e.Value = mask
+		e.Msg = redact(e.Msg, values...)
+		if e.Err != nil {
+			e.Err = secretError{err: e.Err, values: values}
+		}
+	}
+	return e
+}
+
+// Errors 一次 mapper 过程中收集到的所有字段错误
+// mapper 不会在遇到第一个错误时停止，而是遍历完整个结构体之后一起返回
+//
+//	var errs config.Errors
+//	if errors.As(err, &errs) {
+//		for _, e := range errs {
+//			fmt.Println(e.Path, e.Key, e.Value, e.Msg)
+//		}
+//	}
+//
+// 也可以直接通过 errors.As(err, &ConfigError{}) 获取第一个错误
+type Errors []ConfigError
+
+// Error error interface
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "%d errors occurred:", len(e))
+	for _, err := range e {
+		b.WriteString("\n\t* ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Is 只要有一个错误满足 errors.Is 即返回 true
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As 依次对每一个错误调用 errors.As，返回第一个满足条件的结果
+func (e Errors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
 }
 
 // 预定义的一些异常
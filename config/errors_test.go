@@ -2,6 +2,7 @@ package config
 
 import (
 	"github.com/pkg/errors"
+	"strconv"
 	"testing"
 )
 
@@ -12,7 +13,73 @@ func TestConfigError_Error(t *testing.T) {
 	}
 
 	err = wrapE("hello", errors.New("some error"))
-	if err.Error() != errors.Wrap(err.err, err.msg).Error() {
+	if err.Error() != errors.Wrap(err.Err, err.Msg).Error() {
 		t.Fatalf("unexpected result: %s", err.Error())
 	}
 }
+
+func TestConfigError_Field(t *testing.T) {
+	err := ConfigError{Path: "Config.Port", Key: "PORT", Value: "abc", Msg: "setInt64", Err: strconv.ErrSyntax}
+	expect := `Config.Port (PORT="abc"): setInt64: invalid syntax`
+	if err.Error() != expect {
+		t.Fatalf("unexpected result: %s", err.Error())
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatalf("expect unwrap to strconv.ErrSyntax")
+	}
+}
+
+// 测试收集所有字段的错误
+func TestErrors(t *testing.T) {
+	type DB struct {
+		Host string
+		Port int `env:"DB_PORT,abc"`
+	}
+	type Config struct {
+		Name  string
+		Debug bool `env:"DEBUG,yes"`
+		DB    DB
+	}
+	var c Config
+	err := MustMapFrom(&c, nil)
+	if err == nil {
+		t.Fatalf("expect error returned")
+	}
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expect Errors, got: %T", err)
+	}
+	expect := []struct{ path, key, value string }{
+		{"Config.Name", "NAME", ""},
+		{"Config.Debug", "DEBUG", "yes"},
+		{"Config.DB.Host", "HOST", ""},
+		{"Config.DB.Port", "DB_PORT", "abc"},
+	}
+	if len(errs) != len(expect) {
+		t.Fatalf("expect %d errors, got: %s", len(expect), err)
+	}
+	for i, e := range expect {
+		if errs[i].Path != e.path || errs[i].Key != e.key || errs[i].Value != e.value {
+			t.Fatalf("unexpected error at %d: %+v", i, errs[i])
+		}
+	}
+
+	var first ConfigError
+	if !errors.As(err, &first) || first.Path != "Config.Name" {
+		t.Fatalf("expect first ConfigError, got: %+v", first)
+	}
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) || numErr.Num != "yes" {
+		t.Fatalf("expect strconv.NumError, got: %v", numErr)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatalf("expect strconv.ErrSyntax")
+	}
+
+	// 非 strict 模式只返回转换错误
+	err = MapFrom(&c, nil)
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("expect 2 errors, got: %v", err)
+	}
+}
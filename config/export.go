@@ -0,0 +1,291 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Var 导出的一个配置项
+type Var struct {
+	Key    string
+	Value  string
+	Secret bool
+}
+
+// Vars 从结构体导出的所有配置项，按字段顺序排列
+// Vars 本身实现了 Source，Load(&c, vars) 可以还原出导出之前的配置，
+// Vars 中的空值表示字段原来就是空值，总是允许为空，不会使用默认值
+type Vars []Var
+
+// Export 把 src 导出为 KEY=VALUE 的形式，src 可以是结构体或者结构体指针，
+// key 的命名规则、分隔符等和 MapConfig 相同，nil 指针和空的结构体切片会被忽略
+//
+// 敏感字段在 String、Redacted 中会被替换为 ******，Environ、Map 中保留原始值：
+//
+//	vars, _ := config.Export(&c)
+//	log.Println(vars)          // 记录配置，敏感字段已替换
+//	cmd.Env = vars.Environ()   // 传递给子进程
+func Export(src interface{}) (Vars, error) {
+	return newMapper(false).export(src)
+}
+
+// Export 同 Export，但是会使用 l 的 Prefix、AutoPrefix 设置
+func (l *Loader) Export(src interface{}) (Vars, error) {
+	return l.newMapper().export(src)
+}
+
+// setExported 记录值是否来自 Vars，只有 key 本身（suffix 为空）的值需要区分
+func (t *data) setExported(s Source, suffix string) {
+	if suffix == "" {
+		_, t.exported = s.(Vars)
+	}
+}
+
+// Lookup Source interface
+func (vs Vars) Lookup(key string) (string, bool) {
+	for _, v := range vs {
+		if v.Key == key {
+			return v.Value, true
+		}
+	}
+	return "", false
+}
+
+// Keys Lister interface
+func (vs Vars) Keys() []string {
+	result := make([]string, 0, len(vs))
+	for _, v := range vs {
+		result = append(result, v.Key)
+	}
+	return result
+}
+
+// Name 用于 Loader.Audit 中显示配置的来源
+func (vs Vars) Name() string {
+	return "vars"
+}
+
+// Environ 返回 KEY=VALUE 形式的列表，保留敏感字段的原始值，可以直接用于 exec.Cmd 的 Env
+func (vs Vars) Environ() []string {
+	result := make([]string, 0, len(vs))
+	for _, v := range vs {
+		result = append(result, v.Key+"="+v.Value)
+	}
+	return result
+}
+
+// Redacted 返回 KEY=VALUE 形式的列表，敏感字段的值被替换为 ******
+func (vs Vars) Redacted() []string {
+	result := make([]string, 0, len(vs))
+	for _, v := range vs {
+		result = append(result, v.Key+"="+v.redacted())
+	}
+	return result
+}
+
+// Map 返回 key-value 形式的配置，保留敏感字段的原始值
+func (vs Vars) Map() map[string]string {
+	result := make(map[string]string, len(vs))
+	for _, v := range vs {
+		result[v.Key] = v.Value
+	}
+	return result
+}
+
+// String fmt.Stringer，每行一个 KEY=VALUE，敏感字段的值被替换为 ******
+func (vs Vars) String() string {
+	return strings.Join(vs.Redacted(), "\n")
+}
+
+// redacted 返回替换敏感信息之后的值，空值保持为空
+func (v Var) redacted() string {
+	if v.Secret && v.Value != "" {
+		return mask
+	}
+	return v.Value
+}
+
+func (m *mapper) export(src interface{}) (Vars, error) {
+	if src == nil {
+		return nil, ErrorNilInput
+	}
+	v := reflect.ValueOf(src)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, ErrorNilInput
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, ErrorNonStruct
+	}
+	var vs Vars
+	if err := m.exportStruct(v, v.Type().Name(), m.prefix, &vs); err != nil {
+		return nil, err
+	}
+	return vs, nil
+}
+
+// exportStruct 和 mapStruct 一样遍历结构体的字段，把字段的值转为字符串
+func (m *mapper) exportStruct(v reflect.Value, path, prefix string, vs *Vars) error {
+	for _, data := range fields(v.Type()) {
+		ft := data.typ
+		data.path = joinPath(path, ft.Name)
+		data.prefix = prefix + m.nestedPrefix(data)
+		data.addPrefix(prefix)
+		data.addKindSuffix()
+
+		fv := indirect(data.field(v))
+		if !fv.IsValid() {
+			continue
+		}
+		switch {
+		case data.isJSON():
+			// format:"json" 的字段作为一个整体导出为 JSON
+		case hasFactory(ft.Type):
+			// 注册了实现的接口字段导出为 KEY_KIND 和实现中的字段
+			if fv.IsNil() {
+				continue
+			}
+			impl := fv.Elem()
+			kind, ok := kindOf(ft.Type, impl)
+			if !ok {
+				return fieldE(data, newE("unregistered implementation: %s", impl.Type()))
+			}
+			*vs = append(*vs, Var{Key: data.key, Value: kind})
+			if err := m.exportStruct(impl.Elem(), data.path, data.prefix, vs); err != nil {
+				return err
+			}
+			continue
+		case isStructSlice(ft.Type):
+			for j := 0; j < fv.Len(); j++ {
+				elem := indirect(fv.Index(j))
+				if !elem.IsValid() {
+					continue
+				}
+				path, prefix := fmt.Sprintf("%s[%d]", data.path, j), fmt.Sprintf("%s_%d_", data.key, j)
+				if err := m.exportStruct(elem, path, prefix, vs); err != nil {
+					return err
+				}
+			}
+			continue
+		case isStruct(fv.Type()):
+			if err := m.exportStruct(fv, data.path, data.prefix, vs); err != nil {
+				return err
+			}
+			continue
+		}
+		val, err := m.formatValue(fv, data)
+		if err != nil {
+			return fieldE(data, err)
+		}
+		// 读取时会展开 ${KEY}，需要转义才能还原，敏感字段的值不会被展开
+		if !data.secret {
+			val = escapeInterpolation(val)
+		}
+		*vs = append(*vs, Var{Key: data.key, Value: val, Secret: data.secret})
+	}
+	return nil
+}
+
+// formatValue 是 setFieldValue 的逆过程，把 v 转为 setFieldValue 可以解析的字符串
+func (m *mapper) formatValue(v reflect.Value, d *data) (string, error) {
+	if !v.IsValid() {
+		return "", nil
+	}
+	if d.isJSON() {
+		b, err := json.Marshal(v.Interface())
+		return string(b), err
+	}
+	switch i := v.Interface().(type) {
+	case time.Duration:
+		return i.String(), nil
+	case time.Time:
+		layout := time.RFC3339
+		if d.layout != "" {
+			layout = d.layout
+		}
+		return i.Format(layout), nil
+	case time.Location:
+		return i.String(), nil
+	case net.IP:
+		return i.String(), nil
+	case url.URL:
+		return i.String(), nil
+	case regexp.Regexp:
+		return i.String(), nil
+	}
+	if v.CanAddr() {
+		if marshaler, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+			b, err := marshaler.MarshalText()
+			return string(b), err
+		}
+	}
+	if marshaler, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, err := marshaler.MarshalText()
+		return string(b), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// 注册了 DecodeFunc 的类型（如 zerolog.Level）通常实现了 fmt.Stringer
+		if _, ok := getDecoder(v.Type()); ok {
+			return fmt.Sprint(v.Interface()), nil
+		}
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if _, ok := getDecoder(v.Type()); ok {
+			return fmt.Sprint(v.Interface()), nil
+		}
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Slice, reflect.Array:
+		items := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			item, err := m.formatValue(indirect(v.Index(i)), d)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, item)
+		}
+		return strings.Join(items, d.separator()), nil
+	case reflect.Map:
+		pairs := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := m.formatValue(iter.Key(), d)
+			if err != nil {
+				return "", err
+			}
+			val, err := m.formatValue(indirect(iter.Value()), d)
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, key+"="+val)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, d.separator()), nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return "", nil
+		}
+		return m.formatValue(v.Elem(), d)
+	}
+	return fmt.Sprint(v.Interface()), nil
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type exportConfig struct {
+	Name     string         `env:"NAME,pkg"`
+	Port     int            `env:"PORT,8080"`
+	Rate     float64        `env:"RATE,0.5"`
+	Debug    bool           `env:"DEBUG,true"`
+	Timeout  time.Duration  `env:"TIMEOUT,5s"`
+	Day      time.Time      `env:"DAY,2021-03-01" layout:"2006-01-02"`
+	IP       net.IP         `env:"IP,127.0.0.1"`
+	URL      *url.URL       `env:"URL,https://ningzi.club/a?b=c"`
+	Pattern  *regexp.Regexp `env:"PATTERN,^a+$"`
+	Location *time.Location `env:"LOCATION,UTC"`
+	Hosts    []string       `env:"HOSTS" default:"a;b" sep:";"`
+	Ports    [2]uint16      `env:"PORTS,80,443"`
+	Labels   map[string]int `env:"LABELS,b=2,a=1"`
+	Point    point          `env:"POINT,1:2"`
+	Password string         `env:"PASSWORD,p@ss" secret:"true"`
+	Token    Secret         `env:"TOKEN,t0ken"`
+	Primary  struct{ Host string }
+	Replica  *struct{ Host string }
+	Servers  []struct{ Addr string }
+	Args     map[string]interface{} `env:"ARGS,x=1"`
+	Skip     string                 `env:"-"`
+	private  string
+}
+
+// MarshalText 实现 encoding.TextMarshaler，用于测试 Export
+func (p point) MarshalText() ([]byte, error) {
+	return []byte(p.X + ":" + p.Y), nil
+}
+
+func TestExport(t *testing.T) {
+	var c exportConfig
+	source := Map(map[string]string{"PRIMARY_HOST": "primary", "SERVERS_0_ADDR": "s0", "SERVERS_1_ADDR": "s1"})
+	loader := &Loader{Sources: []Source{source}, AutoPrefix: true}
+	if err := loader.Load(&c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.Replica = nil
+
+	vars, err := loader.Export(&c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := []string{
+		"NAME=pkg", "PORT=8080", "RATE=0.5", "DEBUG=true", "TIMEOUT=5s", "DAY=2021-03-01",
+		"IP=127.0.0.1", "URL=https://ningzi.club/a?b=c", "PATTERN=^a+$", "LOCATION=UTC",
+		"HOSTS=a;b", "PORTS=80,443", "LABELS=a=1,b=2", "POINT=1:2", "PASSWORD=p@ss", "TOKEN=t0ken",
+		"PRIMARY_HOST=primary", "SERVERS_0_ADDR=s0", "SERVERS_1_ADDR=s1", "ARGS=x=1",
+	}
+	if !reflect.DeepEqual(vars.Environ(), expect) {
+		t.Fatalf("unexpected environ: %v", vars.Environ())
+	}
+
+	s := vars.String()
+	if strings.Contains(s, "p@ss") || strings.Contains(s, "t0ken") || !strings.Contains(s, "PASSWORD=******\nTOKEN=******") {
+		t.Fatalf("secret should be masked: %s", s)
+	}
+	if m := vars.Map(); m["PASSWORD"] != "p@ss" || len(m) != len(expect) {
+		t.Fatalf("unexpected map: %v", m)
+	}
+
+	// Export 之后再 Load 可以还原
+	var c1 exportConfig
+	if err := (&Loader{Sources: []Source{vars}, AutoPrefix: true}).Load(&c1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c1.Replica = nil
+	if !reflect.DeepEqual(c, c1) {
+		t.Fatalf("round trip failed:\n%+v\n%+v", c, c1)
+	}
+
+	// 空值同样可以还原，不会使用默认值
+	type Empty struct {
+		Name  string   `env:"NAME,def"`
+		Hosts []string `env:"HOSTS,a" required:"true"`
+	}
+	vars, err = Export(Empty{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := Empty{Name: "x"}
+	if err := MustLoad(&e, vars); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Name != "" || e.Hosts != nil {
+		t.Fatalf("unexpected value: %+v", e)
+	}
+}
+
+func TestExportDecoder(t *testing.T) {
+	RegisterDecoder(reflect.TypeOf(zerolog.Level(0)), func(s string) (interface{}, error) {
+		return zerolog.ParseLevel(s)
+	})
+	defer RegisterDecoder(reflect.TypeOf(zerolog.Level(0)), nil)
+
+	vars, err := (&Loader{Prefix: "APP_"}).Export(struct {
+		Level zerolog.Level
+	}{zerolog.WarnLevel})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if vars.String() != "APP_LEVEL=warn" {
+		t.Fatalf("unexpected result: %s", vars)
+	}
+
+	if _, err := Export(nil); err == nil {
+		t.Fatalf("expect error returned")
+	}
+	if _, err := Export((*exportConfig)(nil)); err == nil {
+		t.Fatalf("expect error returned")
+	}
+	if _, err := Export(1); err == nil {
+		t.Fatalf("expect error returned")
+	}
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// kindSuffix 接口字段的 key 的后缀，如字段 Storage 的 key 为 STORAGE_KIND，值用于选择接口的实现
+const kindSuffix = "_KIND"
+
+// FactoryFunc 创建接口的一个实现，返回值必须是实现了接口的结构体指针
+type FactoryFunc func() interface{}
+
+// factories 用户注册的接口实现，接口类型 -> kind -> FactoryFunc
+var factories = struct {
+	sync.RWMutex
+	m map[reflect.Type]map[string]FactoryFunc
+}{m: make(map[reflect.Type]map[string]FactoryFunc)}
+
+// RegisterFactory 为接口类型 iface 注册一个名为 kind 的实现，重复注册会覆盖之前的实现
+// 注册之后，iface 类型的字段从 KEY_KIND 中读取 kind，使用对应的 factory 创建结构体指针，
+// 再按嵌套结构体的规则读取其中的字段，例如：
+//
+//	config.RegisterFactory(reflect.TypeOf((*Storage)(nil)).Elem(), "s3", func() interface{} {
+//		return &S3Config{}
+//	})
+//
+//	type Config struct {
+//		Storage Storage `env:"STORAGE,local"` // STORAGE_KIND=s3 时使用 S3Config，默认值是 kind
+//	}
+//
+// 如果 factory 为 nil，则删除 iface 已注册的 kind
+func RegisterFactory(iface reflect.Type, kind string, factory FactoryFunc) {
+	factories.Lock()
+	defer factories.Unlock()
+	if factory == nil {
+		delete(factories.m[iface], kind)
+		if len(factories.m[iface]) == 0 {
+			delete(factories.m, iface)
+		}
+		return
+	}
+	if factories.m[iface] == nil {
+		factories.m[iface] = make(map[string]FactoryFunc)
+	}
+	factories.m[iface][kind] = factory
+}
+
+// hasFactory 判断 t 是否是注册了实现的接口类型
+func hasFactory(t reflect.Type) bool {
+	if t.Kind() != reflect.Interface {
+		return false
+	}
+	factories.RLock()
+	defer factories.RUnlock()
+	_, ok := factories.m[t]
+	return ok
+}
+
+// getFactory 返回接口类型 t 中名为 kind 的实现
+func getFactory(t reflect.Type, kind string) (FactoryFunc, bool) {
+	factories.RLock()
+	defer factories.RUnlock()
+	factory, ok := factories.m[t][kind]
+	return factory, ok
+}
+
+// kinds 返回接口类型 t 中所有实现的名字，已排序
+func kinds(t reflect.Type) []string {
+	factories.RLock()
+	defer factories.RUnlock()
+	result := make([]string, 0, len(factories.m[t]))
+	for kind := range factories.m[t] {
+		result = append(result, kind)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// kindOf 返回 v 的实际类型在接口类型 t 中注册的名字，用于 Export
+func kindOf(t reflect.Type, v reflect.Value) (string, bool) {
+	for _, kind := range kinds(t) {
+		factory, ok := getFactory(t, kind)
+		if ok && reflect.TypeOf(factory()) == v.Type() {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// addKindSuffix 注册了实现的接口字段的 key 加上 _KIND 后缀，字段的值是实现的名字
+// 需要在 nestedPrefix 之后调用，实现中的字段的前缀不包含后缀
+func (t *data) addKindSuffix() {
+	if !hasFactory(t.typ.Type) {
+		return
+	}
+	t.key += kindSuffix
+	aliases := make([]string, 0, len(t.aliases))
+	for _, key := range t.aliases {
+		aliases = append(aliases, key+kindSuffix)
+	}
+	deprecated := make([]string, 0, len(t.deprecated))
+	for _, key := range t.deprecated {
+		deprecated = append(deprecated, key+kindSuffix)
+	}
+	t.aliases, t.deprecated = aliases, deprecated
+}
+
+// setImpl 使用 kind 对应的 factory 创建 v 的值，实现中的字段使用 d.prefix 作为前缀
+func (m *mapper) setImpl(v reflect.Value, kind string, d *data) error {
+	factory, ok := getFactory(v.Type(), kind)
+	if !ok {
+		return newE("unknown kind %q, expect one of: %s", kind, strings.Join(kinds(v.Type()), "|"))
+	}
+	impl := reflect.ValueOf(factory())
+	if !impl.IsValid() || impl.Kind() != reflect.Ptr || impl.IsNil() ||
+		impl.Elem().Kind() != reflect.Struct || !impl.Type().Implements(v.Type()) {
+		return newE("factory of kind %q should return a struct pointer implementing %s", kind, v.Type())
+	}
+	m.mapStruct(impl.Elem(), d.path, d.prefix)
+	v.Set(impl)
+	return nil
+}
+
+// describeImpls 描述接口类型 t 每个实现中的字段，路径中带上实现的名字，如 Config.Storage(s3).Bucket
+// 只有默认 kind 的实现中的字段可能是必填的，多个实现中相同的 key 只保留第一个，默认 kind 排在最前面
+func (m *mapper) describeImpls(t reflect.Type, d *data, desc *Description) {
+	def := m.defaultValue(d)
+	names := kinds(t)
+	sort.SliceStable(names, func(i, j int) bool { return names[i] == def && names[j] != def })
+	seen := make(map[string]bool, len(*desc))
+	for _, f := range *desc {
+		seen[f.Key] = true
+	}
+	for _, kind := range names {
+		factory, _ := getFactory(t, kind)
+		impl := reflect.TypeOf(factory())
+		if impl == nil || impl.Kind() != reflect.Ptr || impl.Elem().Kind() != reflect.Struct || !impl.Implements(t) {
+			continue
+		}
+		var fields Description
+		m.describeStruct(impl.Elem(), d.path+"("+kind+")", d.prefix, &fields)
+		for _, f := range fields {
+			if seen[f.Key] {
+				continue
+			}
+			seen[f.Key] = true
+			if kind != def {
+				f.Required = false
+			}
+			*desc = append(*desc, f)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type storage interface {
+	Name() string
+}
+
+type s3Storage struct {
+	Bucket string `env:"BUCKET"`
+	Region string `env:"REGION,us-east-1"`
+}
+
+func (s *s3Storage) Name() string { return "s3:" + s.Bucket }
+
+type localStorage struct {
+	Dir string `env:"DIR,/tmp"`
+}
+
+func (s *localStorage) Name() string { return "local:" + s.Dir }
+
+func registerStorage(t *testing.T) {
+	iface := reflect.TypeOf((*storage)(nil)).Elem()
+	RegisterFactory(iface, "s3", func() interface{} { return &s3Storage{} })
+	RegisterFactory(iface, "local", func() interface{} { return &localStorage{} })
+	RegisterFactory(iface, "invalid", func() interface{} { return localStorage{} })
+	t.Cleanup(func() {
+		for _, kind := range []string{"s3", "local", "invalid"} {
+			RegisterFactory(iface, kind, nil)
+		}
+	})
+}
+
+func TestMapperFactory(t *testing.T) {
+	type Config struct {
+		Storage storage `env:"STORAGE,local"`
+		Backup  storage `env:"BACKUP"`
+	}
+	// 没有注册时和其他接口一样不支持
+	if err := Load(&Config{}, Map(map[string]string{"BACKUP_KIND": "s3", "BACKUP": "s3"})); err == nil {
+		t.Fatalf("expect error returned")
+	}
+	registerStorage(t)
+
+	loader := &Loader{AutoPrefix: true, Sources: []Source{Map(map[string]string{
+		"BACKUP_KIND":   "s3",
+		"BACKUP_BUCKET": "backup",
+	})}}
+	var c Config
+	if err := loader.Load(&c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Storage.Name() != "local:/tmp" || c.Backup.Name() != "s3:backup" || c.Backup.(*s3Storage).Region != "us-east-1" {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+
+	// Export 输出 KEY_KIND 和实现中的字段
+	vars, err := loader.Export(&c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := []string{"STORAGE_KIND=local", "STORAGE_DIR=/tmp", "BACKUP_KIND=s3", "BACKUP_BUCKET=backup", "BACKUP_REGION=us-east-1"}
+	if !reflect.DeepEqual(vars.Environ(), expect) {
+		t.Fatalf("unexpected vars: %v", vars.Environ())
+	}
+
+	// Describe 中是 KEY_KIND 和每个实现中的字段，默认 kind 的字段排在前面
+	d, _ := (&Loader{AutoPrefix: true, Strict: true}).Describe(&c)
+	keys := make([]string, 0, len(d))
+	for _, f := range d {
+		keys = append(keys, f.Key)
+	}
+	expect = []string{"STORAGE_KIND", "STORAGE_DIR", "STORAGE_BUCKET", "STORAGE_REGION",
+		"BACKUP_KIND", "BACKUP_DIR", "BACKUP_BUCKET", "BACKUP_REGION"}
+	if !reflect.DeepEqual(keys, expect) || d[0].Default != "local" {
+		t.Fatalf("unexpected description: %+v", d)
+	}
+	// 只有默认 kind 的字段是必填的
+	if d[2].Path != "Config.Storage(s3).Bucket" || !d[1].Required || d[2].Required || d[5].Required {
+		t.Fatalf("unexpected description: %+v", d)
+	}
+
+	// 没有设置 kind 时字段保持为 nil，strict 模式下返回错误
+	var strict struct {
+		Backup storage `env:"BACKUP"`
+	}
+	var e ConfigError
+	if err := MustLoad(&strict); !errors.As(err, &e) || e.Key != "BACKUP_KIND" || e.Msg != "missing value" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for kind, msg := range map[string]string{
+		"gcs":     `unknown kind "gcs", expect one of: invalid|local|s3`,
+		"invalid": "should return a struct pointer",
+	} {
+		err := Load(&Config{}, Map(map[string]string{"BACKUP_KIND": kind}))
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+)
+
+// FlagSource 从命令行参数读取配置的 Source，每个配置项对应一个 flag，
+// flag 名是去掉 Loader.Prefix 之后的 key 的小写形式，_ 替换为 -，如 APP_DB_HOST 对应 --db-host
+// 只有在命令行中设置了的 flag 才会被 Lookup 找到，所以 FlagSource 应该放在 Sources 的最前面：
+//
+//	loader := &config.Loader{Sources: []config.Source{config.Env(), dotenv}, Prefix: "APP_"}
+//	flags, err := loader.Flags(&c, nil)
+//	flag.Parse()
+//	loader.Sources = append([]config.Source{flags}, loader.Sources...)
+//	err = loader.Load(&c) // flag > env > file > default
+type FlagSource struct {
+	values map[string]*flagValue // key -> flag 的值
+	keys   []string
+}
+
+// Flags 根据 dest 中的配置项在 fs 中注册 flag，fs 为 nil 时使用 flag.CommandLine
+// 同 Loader.Flags
+func Flags(dest interface{}, fs *flag.FlagSet) (*FlagSource, error) {
+	return (&Loader{}).Flags(dest, fs)
+}
+
+// Flags 根据 dest 中的配置项在 fs 中注册 flag，fs 为 nil 时使用 flag.CommandLine
+// flag 的帮助信息由 desc 标签、类型、默认值和 key 生成，敏感字段不显示默认值
+// 结构体切片中的字段没有对应的 flag，和 fs 中已有的 flag 重名时返回错误
+func (l *Loader) Flags(dest interface{}, fs *flag.FlagSet) (*FlagSource, error) {
+	d, err := l.Describe(dest)
+	if err != nil {
+		return nil, err
+	}
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	s := &FlagSource{values: make(map[string]*flagValue, len(d))}
+	for _, f := range d {
+		// 结构体切片的长度由 key 决定，无法通过 flag 设置
+		if strings.Contains(f.Path, "[") {
+			continue
+		}
+		name := flagName(strings.TrimPrefix(f.Key, l.Prefix))
+		if fs.Lookup(name) != nil {
+			return nil, newE("flag redefined: %s", name)
+		}
+		v := &flagValue{}
+		if !f.Secret {
+			v.val = f.Default
+		}
+		var value flag.Value = v
+		if f.typ.Kind() == reflect.Bool {
+			b := &boolFlag{flagValue: *v}
+			value, v = b, &b.flagValue
+		}
+		fs.Var(value, name, flagUsage(f))
+		s.values[f.Key] = v
+		s.keys = append(s.keys, f.Key)
+	}
+	return s, nil
+}
+
+// Lookup Source interface，只返回命令行中设置了的 flag
+func (s *FlagSource) Lookup(key string) (string, bool) {
+	v, ok := s.values[key]
+	if !ok || !v.set {
+		return "", false
+	}
+	return v.val, true
+}
+
+// Keys Lister interface，只返回命令行中设置了的 flag 对应的 key
+func (s *FlagSource) Keys() []string {
+	result := make([]string, 0)
+	for _, key := range s.keys {
+		if s.values[key].set {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+// Name 用于 Loader.Audit 中显示配置的来源
+func (s *FlagSource) Name() string {
+	return "flag"
+}
+
+// flagName 把 key 转为 flag 名，如 DB_HOST 转为 db-host
+func flagName(key string) string {
+	return strings.Replace(strings.ToLower(key), "_", "-", -1)
+}
+
+// flagUsage 生成 flag 的帮助信息，如 "listen port (int, required, env APP_PORT)"
+// 反引号中的类型会被 flag 包用作参数名，如 -port int，bool 类型的 flag 不需要参数名
+func flagUsage(f Field) string {
+	attrs := []string{"`" + f.Type + "`"}
+	if f.typ.Kind() == reflect.Bool {
+		attrs[0] = f.Type
+	}
+	if f.Required {
+		attrs = append(attrs, "required")
+	}
+	if f.Secret {
+		attrs = append(attrs, "secret")
+	}
+	attrs = append(attrs, "env "+f.Key)
+	if f.Desc == "" {
+		return "(" + strings.Join(attrs, ", ") + ")"
+	}
+	return f.Desc + " (" + strings.Join(attrs, ", ") + ")"
+}
+
+// flagValue flag.Value，val 在注册时是默认值，用于帮助信息，set 表示是否在命令行中设置过
+type flagValue struct {
+	val string
+	set bool
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.val
+}
+
+func (v *flagValue) Set(s string) error {
+	v.val, v.set = s, true
+	return nil
+}
+
+// boolFlag bool 类型的 flag，可以省略值，如 --debug 等同于 --debug=true
+type boolFlag struct {
+	flagValue
+}
+
+func (b *boolFlag) IsBoolFlag() bool {
+	return true
+}
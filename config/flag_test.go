@@ -0,0 +1,69 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoaderFlags(t *testing.T) {
+	type Config struct {
+		DB struct {
+			Host string `env:"HOST,localhost" desc:"database host"`
+			Port int    `env:"PORT,3306" required:"true"`
+		} `env:"DB"`
+		Debug    bool   `env:"DEBUG"`
+		Password Secret `env:"PASSWORD,default"`
+		Name     string `env:"NAME,pkg"`
+		Servers  []struct {
+			Addr string `env:"ADDR"`
+		}
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	loader := &Loader{Sources: []Source{Map(map[string]string{"APP_DB_HOST": "env", "APP_DB_PORT": "3307", "APP_NAME": "env"})},
+		Prefix: "APP_", AutoPrefix: true}
+	flags, err := loader.Flags(&Config{}, fs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := fs.Parse([]string{"--db-host", "flag", "--debug", "-password=p@ss"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if keys := flags.Keys(); !reflect.DeepEqual(keys, []string{"APP_DB_HOST", "APP_DEBUG", "APP_PASSWORD"}) {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+
+	// flag > env > default
+	loader.Sources = append([]Source{flags}, loader.Sources...)
+	var c Config
+	if err := loader.Load(&c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.DB.Host != "flag" || c.DB.Port != 3307 || !c.Debug || c.Password != "p@ss" || c.Name != "env" {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+
+	var buf bytes.Buffer
+	fs.SetOutput(&buf)
+	fs.PrintDefaults()
+	for _, line := range []string{
+		"  -db-host string\n    \tdatabase host (string, env APP_DB_HOST) (default localhost)\n",
+		"  -db-port int\n    \t(int, required, env APP_DB_PORT) (default 3306)\n",
+		"  -debug\n    \t(bool, env APP_DEBUG)\n",
+		"  -password config.Secret\n    \t(config.Secret, secret, env APP_PASSWORD)\n",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Fatalf("expect %q in usage:\n%s", line, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "servers") {
+		t.Fatalf("struct slice should be ignored:\n%s", buf.String())
+	}
+
+	// 重名
+	if _, err := loader.Flags(&Config{}, fs); err == nil {
+		t.Fatalf("expect error returned")
+	}
+}
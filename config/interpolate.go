@@ -0,0 +1,138 @@
+package config
+
+import (
+	"strings"
+)
+
+// interpolate 展开 s 中引用的其他 key，支持以下写法：
+//
+//	${KEY}            KEY 的值，KEY 不存在时返回错误，KEY 设置为空时为空
+//	${KEY:-fallback}  KEY 不存在或为空时使用 fallback，fallback 中也可以引用其他 key
+//	$${               转义，输出 ${
+//
+// 引用的 key 不会加上 Loader.Prefix 等前缀，从 m.sources 中按同样的优先级查找，
+// 引用的值本身也会被展开，stack 记录正在展开的 key，用于检测循环引用
+// 敏感字段从 sources 和 KEY_FILE 中读到的值不会被展开，参考 resolve，
+// 引用的 key 同样会从 KEY_FILE 中读取，敏感值不会再次展开，参考 lookupRef
+func (m *mapper) interpolate(s string, stack []string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$${") {
+			b.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(s[i:], "${") {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		end := closingBrace(s, i+2)
+		if end < 0 {
+			return "", newE("interpolate: missing closing brace")
+		}
+		name, fallback := s[i+2:end], ""
+		j := strings.Index(name, ":-")
+		if j >= 0 {
+			name, fallback = name[:j], name[j+2:]
+		}
+		if name == "" {
+			return "", newE("interpolate: empty variable name")
+		}
+		val, ok, err := m.expand(name, stack)
+		if err != nil {
+			return "", err
+		}
+		// 拼写错误的 key 会被悄悄替换为空，所以没有 fallback 时 key 必须存在
+		if !ok && j < 0 {
+			return "", newE("interpolate: %s is not set", name)
+		}
+		if val == "" && j >= 0 {
+			if val, err = m.interpolate(fallback, stack); err != nil {
+				return "", err
+			}
+		}
+		b.WriteString(val)
+		i = end + 1
+	}
+	return b.String(), nil
+}
+
+// expand 查找 name 的值并展开，ok 表示 name 是否存在
+func (m *mapper) expand(name string, stack []string) (val string, ok bool, err error) {
+	for _, key := range stack {
+		if key == name {
+			return "", false, newE("interpolate: cycle detected: %s -> %s", strings.Join(stack, " -> "), name)
+		}
+	}
+	val, ok, secret, err := m.lookupRef(name)
+	if err != nil || !ok {
+		return "", ok, err
+	}
+	// 敏感值（如密码）中可能包含 ${，不再展开
+	if secret {
+		return val, true, nil
+	}
+	// 复制一份 stack，避免不同分支之间互相影响
+	val, err = m.interpolate(val, append(stack[:len(stack):len(stack)], name))
+	return val, true, err
+}
+
+// lookupRef 查找引用的 key，和 resolve 一样，key 没有值时从 KEY_FILE 指向的文件中读取
+// secret 表示值是否是敏感值，即来自 KEY_FILE 或者 key 是敏感字段的 key
+func (m *mapper) lookupRef(name string) (val string, ok, secret bool, err error) {
+	m.markKnown(name)
+	if val, ok = lookup(m.sources, name); ok && val != "" {
+		return val, true, m.isSecretKey(name), nil
+	}
+	if path, _ := lookup(m.sources, name+fileSuffix); path != "" {
+		m.markKnown(name + fileSuffix)
+		val, err = readSecretFile(path)
+		return val, true, true, err
+	}
+	return val, ok, m.isSecretKey(name), nil
+}
+
+// isSecretKey 判断 key 是否是 dest 中敏感字段的 key，第一次调用时通过 describeStruct 找出所有敏感字段
+func (m *mapper) isSecretKey(key string) bool {
+	if m.secrets == nil {
+		m.secrets = make(map[string]struct{})
+		if m.typ != nil {
+			var d Description
+			m.describeStruct(m.typ, m.typ.Name(), m.prefix, &d)
+			for _, f := range d {
+				if f.Secret {
+					m.secrets[f.Key] = struct{}{}
+				}
+			}
+		}
+	}
+	_, ok := m.secrets[key]
+	return ok
+}
+
+// escapeInterpolation 是 interpolate 的逆过程，把 ${ 转义为 $${，用于 Export
+func escapeInterpolation(s string) string {
+	return strings.Replace(s, "${", "$${", -1)
+}
+
+// closingBrace 返回从 start 开始与 ${ 匹配的 } 的位置，支持嵌套，没有找到时返回 -1
+func closingBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "${"):
+			depth++
+			i++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
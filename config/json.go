@@ -0,0 +1,22 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// isJSON 判断字段是否设置了 format:"json"
+// 设置之后字段作为一个整体从 JSON 中解析，结构体、结构体切片不会再按嵌套的规则读取
+func (t *data) isJSON() bool {
+	return t != nil && t.format == formatJSON
+}
+
+// setJSON 使用 encoding/json 把 value 解析为 v 的类型，可以是结构体、map、切片以及它们的指针
+func (m *mapper) setJSON(v reflect.Value, value string) error {
+	ptr := reflect.New(v.Type())
+	if err := json.Unmarshal([]byte(value), ptr.Interface()); err != nil {
+		return wrapE("setJSON", err)
+	}
+	v.Set(ptr.Elem())
+	return nil
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMapperJSON(t *testing.T) {
+	type Index struct {
+		Shards   int `json:"shards"`
+		Replicas int `json:"replicas"`
+	}
+	type Server struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	type Config struct {
+		Args     map[string]interface{} `env:"ARGS" format:"json"`
+		Index    Index                  `env:"INDEX" format:"json" default:"{\"shards\":1,\"replicas\":0}"`
+		Settings *Index                 `env:"SETTINGS" format:"json"`
+		Servers  []Server               `env:"SERVERS" format:"json"`
+		Matrix   [][]int                `env:"MATRIX" format:"json"`
+		Empty    *Index                 `env:"EMPTY" format:"json"`
+	}
+	values := map[string]string{
+		"ARGS":     `{"x-max-priority": 10, "x-queue-mode": "lazy"}`,
+		"SETTINGS": `{"shards": 3}`,
+		"SERVERS":  `[{"host": "a", "port": 80}, {"host": "b", "port": 443}]`,
+		"MATRIX":   `[[1, 2], [3]]`,
+		// 结构体切片不再从带下标的 key 中读取
+		"SERVERS_0_HOST": "ignored",
+	}
+	var c Config
+	if err := MapFrom(&c, values); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := Config{
+		Args:     map[string]interface{}{"x-max-priority": float64(10), "x-queue-mode": "lazy"},
+		Index:    Index{Shards: 1},
+		Settings: &Index{Shards: 3},
+		Servers:  []Server{{Host: "a", Port: 80}, {Host: "b", Port: 443}},
+		Matrix:   [][]int{{1, 2}, {3}},
+	}
+	if !reflect.DeepEqual(c, expect) {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+
+	// Export 输出为 JSON，可以再次读取
+	vars, err := Export(&c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, _ := vars.Lookup("SERVERS"); v != `[{"host":"a","port":80},{"host":"b","port":443}]` {
+		t.Fatalf("unexpected value: %s", v)
+	}
+	var c1 Config
+	if err := Load(&c1, vars); err != nil || !reflect.DeepEqual(c1, expect) {
+		t.Fatalf("unexpected result: %+v, %v", c1, err)
+	}
+
+	// Describe 中作为一个字段
+	d, _ := Describe(&c)
+	if len(d) != 6 || d[1].Key != "INDEX" {
+		t.Fatalf("unexpected description: %+v", d)
+	}
+	b, _ := d.JSONSchema()
+	var schema struct {
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}
+	_ = json.Unmarshal(b, &schema)
+	index := schema.Properties["INDEX"]
+	if index["type"] != "object" || !reflect.DeepEqual(index["default"], map[string]interface{}{"shards": float64(1), "replicas": float64(0)}) {
+		t.Fatalf("unexpected property: %v", index)
+	}
+
+	// 必填和错误
+	var e struct {
+		Index Index `env:"INDEX" format:"json" required:"true"`
+	}
+	if err := MapFrom(&e, nil); err == nil || !strings.Contains(err.Error(), "missing value") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := MapFrom(&e, map[string]string{"INDEX": "{shards: 1}"}); err == nil || !strings.Contains(err.Error(), "setJSON") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"reflect"
+	"sync"
+)
+
+// plans 缓存每个结构体类型中需要处理的字段，key 是 reflect.Type，value 是 []*data
+// 标签的解析结果只和结构体的定义有关，第一次处理某个类型时解析，之后直接复用，
+// 热加载、多租户等需要频繁 mapper 同一个类型的场景下，不需要每次都重新解析标签和生成 key
+var plans sync.Map
+
+// getPlan 返回 t 中需要处理的字段解析好的 data，不包含非导出字段和忽略的字段
+// 返回的 data 是共享的，不能修改，需要修改时使用 fields
+func getPlan(t reflect.Type) []*data {
+	if plan, ok := plans.Load(t); ok {
+		return plan.([]*data)
+	}
+	plan := make([]*data, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		d := getData(t.Field(i))
+		if d.shouldSkip() {
+			continue
+		}
+		plan = append(plan, d)
+	}
+	actual, _ := plans.LoadOrStore(t, plan)
+	return actual.([]*data)
+}
+
+// fields 返回 t 中需要处理的字段，每个 data 都是缓存的副本，可以直接修改 key、val 等字段
+// aliases、rules 等切片和缓存共享，只能整体替换，不能修改其中的元素
+func fields(t reflect.Type) []*data {
+	plan := getPlan(t)
+	result := make([]*data, len(plan))
+	for i, d := range plan {
+		c := *d
+		result[i] = &c
+	}
+	return result
+}
+
+// field 返回 data 对应的字段的值
+func (t *data) field(v reflect.Value) reflect.Value {
+	return v.Field(t.typ.Index[0])
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanCache(t *testing.T) {
+	type Config struct {
+		Host    string `env:"HOST|ADDR,localhost"`
+		private string
+		Ignored string `env:"-"`
+		Port    int    `validate:"min=1"`
+	}
+	typ := reflect.TypeOf(Config{})
+	plan := getPlan(typ)
+	if len(plan) != 2 || plan[0].key != "HOST" || plan[1].key != "PORT" || plan[1].typ.Index[0] != 3 {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	if again := getPlan(typ); &again[0] != &plan[0] {
+		t.Fatalf("plan should be cached")
+	}
+
+	// fields 返回的是副本，修改之后不影响缓存
+	fs := fields(typ)
+	fs[0].addPrefix("APP_")
+	fs[0].val = "value"
+	if plan[0].key != "HOST" || plan[0].aliases[0] != "ADDR" || plan[0].val != "" {
+		t.Fatalf("plan should not be modified: %+v", plan[0])
+	}
+
+	// 多次 mapper 同一个类型，结果相同
+	for i := 0; i < 3; i++ {
+		var c Config
+		err := (&Loader{Sources: []Source{Map(map[string]string{"APP_ADDR": "db", "APP_PORT": "1"})}, Prefix: "APP_"}).Load(&c)
+		if err != nil || c.Host != "db" || c.Port != 1 {
+			t.Fatalf("unexpected result: %+v, %v", c, err)
+		}
+	}
+}
+
+type benchmarkDB struct {
+	Host     string         `env:"HOST,localhost"`
+	Port     int            `env:"PORT,3306" validate:"min=1,max=65535"`
+	User     string         `env:"USER,root"`
+	Password Secret         `env:"PASSWORD"`
+	Timeout  time.Duration  `env:"TIMEOUT,5s"`
+	Options  []string       `env:"OPTIONS,a,b,c"`
+	MaxOpen  int            `env:"MAX_OPEN,10"`
+	MaxIdle  int            `env:"MAX_IDLE,5"`
+	Labels   map[string]int `env:"LABELS,a=1,b=2"`
+}
+
+type benchmarkConfig struct {
+	Name     string   `env:"NAME,service" desc:"service name"`
+	Debug    bool     `env:"DEBUG,false"`
+	Buffer   ByteSize `env:"BUFFER,64MiB"`
+	Primary  benchmarkDB
+	Replica  benchmarkDB
+	Cache    benchmarkDB
+	Archive  *benchmarkDB
+	Backends []benchmarkDB
+}
+
+func BenchmarkLoadLarge(b *testing.B) {
+	source := Map(map[string]string{
+		"PRIMARY_HOST":        "primary",
+		"REPLICA_HOST":        "replica",
+		"BACKENDS_0_HOST":     "backend0",
+		"BACKENDS_1_HOST":     "backend1",
+		"BACKENDS_2_PASSWORD": "p@ss",
+	})
+	loader := &Loader{Sources: []Source{source}, AutoPrefix: true}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var c benchmarkConfig
+		if err := loader.Load(&c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetData(b *testing.B) {
+	typ := reflect.TypeOf(benchmarkDB{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < typ.NumField(); j++ {
+			_ = getData(typ.Field(j))
+		}
+	}
+}
+
+func BenchmarkFields(b *testing.B) {
+	typ := reflect.TypeOf(benchmarkDB{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = fields(typ)
+	}
+}
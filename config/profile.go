@@ -0,0 +1,53 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// profileSep default 标签和 profile 之间的分隔符，如 `default.prod:"..."`
+const profileSep = "."
+
+// profile 返回当前使用的 profile，Profile 为空时从 Sources 中读取 ProfileKey
+func (l *Loader) profile() string {
+	if l.Profile != "" || l.ProfileKey == "" {
+		return l.Profile
+	}
+	val, _ := lookup(l.Sources, l.ProfileKey)
+	return strings.TrimSpace(val)
+}
+
+// sources 返回 Sources 和 profile 对应的 Profiles，Profiles 的优先级低于 Sources
+func (l *Loader) sources(profile string) []Source {
+	extra := l.Profiles[profile]
+	if len(extra) == 0 {
+		return l.Sources
+	}
+	result := make([]Source, 0, len(l.Sources)+len(extra))
+	result = append(result, l.Sources...)
+	return append(result, extra...)
+}
+
+// allSources 返回 Sources 和所有 Profiles，Watcher 需要监听所有的文件，profile 可能在重新读取之后改变
+func (l *Loader) allSources() []Source {
+	profiles := make([]string, 0, len(l.Profiles))
+	for profile := range l.Profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	result := append([]Source{}, l.Sources...)
+	for _, profile := range profiles {
+		result = append(result, l.Profiles[profile]...)
+	}
+	return result
+}
+
+// defaultValue 返回字段在当前 profile 下的默认值，`default.<profile>` 标签优先于 default 标签
+func (m *mapper) defaultValue(t *data) string {
+	if m.profile != "" {
+		if val, ok := t.typ.Tag.Lookup(tagDefault + profileSep + m.profile); ok {
+			return val
+		}
+	}
+	return t._default
+}
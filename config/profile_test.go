@@ -0,0 +1,78 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestLoaderProfile(t *testing.T) {
+	type Config struct {
+		Debug    bool   `env:"DEBUG,true" default.prod:"false"`
+		LogLevel string `env:"LOG_LEVEL" default:"debug" default.staging:"info" default.prod:"warn"`
+		DBHost   string `env:"DB_HOST,localhost"`
+		Replicas int    `env:"REPLICAS,1"`
+	}
+	prod := Map(map[string]string{"DB_HOST": "db.prod", "REPLICAS": "3"})
+	loader := &Loader{
+		ProfileKey: "APP_ENV",
+		Profiles:   map[string][]Source{"prod": {prod}},
+	}
+	var cases = []struct {
+		env    map[string]string
+		expect Config
+	}{
+		{
+			env:    map[string]string{},
+			expect: Config{Debug: true, LogLevel: "debug", DBHost: "localhost", Replicas: 1},
+		},
+		{
+			env:    map[string]string{"APP_ENV": "staging"},
+			expect: Config{Debug: true, LogLevel: "info", DBHost: "localhost", Replicas: 1},
+		},
+		{
+			env:    map[string]string{"APP_ENV": "prod"},
+			expect: Config{Debug: false, LogLevel: "warn", DBHost: "db.prod", Replicas: 3},
+		},
+		// 环境变量优先于 profile 的 Source 和默认值
+		{
+			env:    map[string]string{"APP_ENV": "prod", "REPLICAS": "5", "LOG_LEVEL": "error"},
+			expect: Config{Debug: false, LogLevel: "error", DBHost: "db.prod", Replicas: 5},
+		},
+	}
+	for i, c := range cases {
+		loader.Sources = []Source{Map(c.env)}
+		var config Config
+		if err := loader.Load(&config); err != nil {
+			t.Fatalf("unexpected error: %v, index: %d", err, i)
+		}
+		if config != c.expect {
+			t.Fatalf("unexpected value: %+v, index: %d", config, i)
+		}
+	}
+
+	// Profile 优先于 ProfileKey
+	loader.Profile = "staging"
+	d, err := loader.Describe(&Config{})
+	if err != nil || d[1].Default != "info" {
+		t.Fatalf("unexpected description: %+v, %v", d, err)
+	}
+	if sources := loader.allSources(); len(sources) != 2 {
+		t.Fatalf("unexpected sources: %v", sources)
+	}
+}
+
+// ProfileKey 不是未知的 key
+func TestLoaderProfileDisallowUnknown(t *testing.T) {
+	type Config struct {
+		Port int `env:"PORT,80"`
+	}
+	loader := &Loader{
+		Sources:         []Source{Map(map[string]string{"APP_ENV": "prod", "APP_PORT": "8080"})},
+		Prefix:          "APP_",
+		ProfileKey:      "APP_ENV",
+		DisallowUnknown: true,
+	}
+	var c Config
+	if err := loader.Load(&c); err != nil || c.Port != 8080 {
+		t.Fatalf("unexpected result: %+v, %v", c, err)
+	}
+}
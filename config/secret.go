@@ -0,0 +1,254 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// mask 敏感信息打印时的替代内容
+const mask = "******"
+
+// fileSuffix 敏感字段会额外查找 KEY_FILE，从这个文件中读取值，
+// 用于 Kubernetes、Docker Swarm 以文件的形式挂载的 secret
+const fileSuffix = "_FILE"
+
+const tagSecret = "secret"
+
+// Secret 敏感信息，如密码、token、包含密码的 DSN
+// 通过 fmt、log 或者 json 输出时会被替换为 ******，需要原始值时使用 string(s)
+// Secret 类型的字段等同于设置了 `secret:"true"` 标签
+type Secret string
+
+var secretType = reflect.TypeOf(Secret(""))
+
+// String fmt.Stringer
+func (s Secret) String() string {
+	return mask
+}
+
+// GoString fmt.GoStringer，用于 %#v
+func (s Secret) GoString() string {
+	return mask
+}
+
+// MarshalJSON json.Marshaler
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + mask + `"`), nil
+}
+
+// isSecret 判断字段是否是敏感字段，secret 标签无效时同样当作敏感字段，避免值被输出
+func isSecret(field reflect.StructField) bool {
+	secret, err := parseSecret(field)
+	return secret || err != nil
+}
+
+// parseSecret 解析 secret 标签，和 required 等标签一样使用 strconv.ParseBool
+func parseSecret(field reflect.StructField) (bool, error) {
+	if indirectType(field.Type) == secretType {
+		return true, nil
+	}
+	tag, ok := field.Tag.Lookup(tagSecret)
+	if !ok {
+		return false, nil
+	}
+	secret, err := strconv.ParseBool(tag)
+	if err != nil {
+		return false, wrapE("invalid secret tag", err)
+	}
+	return secret, nil
+}
+
+// readSecretFile 读取 KEY_FILE 指向的文件，去掉末尾的换行
+func readSecretFile(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", wrapE("read secret file", err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// secretError 把错误信息中的敏感值替换为 ******
+// Unwrap 跳过错误链中包含敏感值的错误（如 *strconv.NumError 的 Num），只保留之后不包含敏感值的部分，
+// errors.Is(err, strconv.ErrSyntax) 等判断仍然有效
+type secretError struct {
+	err    error
+	values []string
+}
+
+// Error error interface
+func (e secretError) Error() string {
+	return redact(e.err.Error(), e.values...)
+}
+
+// Unwrap 返回错误链中第一个之后都不包含敏感值的错误，没有时返回 nil
+func (e secretError) Unwrap() error {
+	for err := errors.Unwrap(e.err); err != nil; err = errors.Unwrap(err) {
+		if !e.leaks(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// leaks 判断 err 以及它之后的错误链中是否包含敏感值
+func (e secretError) leaks(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if msg := err.Error(); redact(msg, e.values...) != msg {
+			return true
+		}
+	}
+	return false
+}
+
+// redact 把 s 中的 values 依次替换为 ******
+// 只替换完整的值（两端不是字母、数字），如 "4" 不会替换 setInt64 中的 4，
+// 同时会替换 strconv.Quote 转义之后的形式，用于 parsing "..." 这样的错误信息
+func redact(s string, values ...string) string {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		s = redactToken(s, value)
+		if quoted := strconv.Quote(value); quoted[1:len(quoted)-1] != value {
+			s = redactToken(s, quoted[1:len(quoted)-1])
+		}
+	}
+	return s
+}
+
+// redactToken 替换 s 中完整出现的 value
+func redactToken(s, value string) string {
+	var b strings.Builder
+	start := 0
+	for {
+		i := strings.Index(s[start:], value)
+		if i < 0 {
+			break
+		}
+		i += start
+		end := i + len(value)
+		b.WriteString(s[start:i])
+		// value 两端是字母、数字时，相邻的字符不能也是字母、数字，否则是其他单词的一部分
+		if isWordByte(value, 0) && isWordByte(s, i-1) || isWordByte(value, len(value)-1) && isWordByte(s, end) {
+			b.WriteString(value)
+		} else {
+			b.WriteString(mask)
+		}
+		start = end
+	}
+	b.WriteString(s[start:])
+	return b.String()
+}
+
+// isWordByte s[i] 是否是字母、数字、_ 或者多字节字符的一部分，i 超出范围时返回 false
+func isWordByte(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return false
+	}
+	c := s[i]
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// secretValues 返回错误信息中需要替换的敏感值
+// slice、array、map 中某个元素解析失败时，错误信息中只包含这个元素，所以分隔之后的每个元素也需要替换，
+// 按长度从长到短排列，避免较短的元素先替换掉较长的值中的一部分
+func (t *data) secretValues() []string {
+	values := []string{t.val}
+	kind := indirectType(t.typ.Type).Kind()
+	if t.isJSON() || (kind != reflect.Slice && kind != reflect.Array && kind != reflect.Map) {
+		return values
+	}
+	for _, part := range strings.Split(t.val, t.separator()) {
+		values = append(values, part, strings.TrimSpace(part))
+		if i := strings.Index(part, "="); i >= 0 && kind == reflect.Map {
+			values = append(values, strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:]))
+		}
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+	return values
+}
+
+// Sprint 同 fmt.Sprintf("%+v", src)，但是敏感字段（`secret:"true"` 或 Secret 类型）的值会被替换为 ******，
+// 空值保持为空，方便判断敏感字段是否已经设置，用于打印、记录配置
+func Sprint(src interface{}) string {
+	var b strings.Builder
+	sprint(&b, reflect.ValueOf(src))
+	return b.String()
+}
+
+func sprint(b *strings.Builder, v reflect.Value) {
+	if !v.IsValid() {
+		b.WriteString("<nil>")
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			b.WriteString("<nil>")
+			return
+		}
+		if isStruct(v.Type().Elem()) {
+			b.WriteString("&")
+		}
+		sprint(b, v.Elem())
+		return
+	case reflect.Struct:
+		if !isStruct(v.Type()) {
+			break
+		}
+		t := v.Type()
+		b.WriteString("{")
+		first := true
+		for i := 0; i < v.NumField(); i++ {
+			ft := t.Field(i)
+			if ft.PkgPath != "" {
+				continue
+			}
+			if !first {
+				b.WriteString(" ")
+			}
+			first = false
+			b.WriteString(ft.Name)
+			b.WriteString(":")
+			fv := v.Field(i)
+			if isSecret(ft) {
+				if !fv.IsZero() {
+					b.WriteString(mask)
+				}
+				continue
+			}
+			sprint(b, fv)
+		}
+		b.WriteString("}")
+		return
+	case reflect.Slice, reflect.Array:
+		if !isStruct(indirectType(v.Type().Elem())) {
+			break
+		}
+		b.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			sprint(b, v.Index(i))
+		}
+		b.WriteString("]")
+		return
+	}
+	_, _ = fmt.Fprintf(b, "%+v", v.Interface())
+}
+
+// indirectType 返回指针类型最终指向的类型
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSecret(t *testing.T) {
+	s := Secret("password")
+	if fmt.Sprint(s) != mask || fmt.Sprintf("%#v", s) != mask || string(s) != "password" {
+		t.Fatalf("secret should be masked")
+	}
+	b, err := json.Marshal(struct{ Password Secret }{s})
+	if err != nil || string(b) != `{"Password":"******"}` {
+		t.Fatalf("unexpected json: %s, %v", b, err)
+	}
+	if strings.Contains(fmt.Sprintf("%+v", struct{ Password Secret }{s}), "password") {
+		t.Fatalf("secret should be masked")
+	}
+}
+
+// 测试从 KEY_FILE 读取敏感字段
+func TestMapperSecretFile(t *testing.T) {
+	type Config struct {
+		Password string `env:"DB_PASSWORD" secret:"true"`
+		Token    Secret `env:"TOKEN"`
+		Plain    string `env:"PLAIN"`
+	}
+	values := map[string]string{
+		"DB_PASSWORD_FILE": writeFile(t, "password", "p@ss\n"),
+		"TOKEN":            "token",
+		"PLAIN_FILE":       writeFile(t, "plain", "ignored"),
+	}
+	var c Config
+	if err := Load(&c, Map(values)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Password != "p@ss" || c.Token != "token" || c.Plain != "" {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+
+	// KEY 优先于 KEY_FILE
+	values["DB_PASSWORD"] = "direct"
+	if err := Load(&c, Map(values)); err != nil || c.Password != "direct" {
+		t.Fatalf("unexpected value: %+v, %v", c, err)
+	}
+
+	// 文件不存在
+	if err := Load(&c, Map(map[string]string{"DB_PASSWORD_FILE": "/not/exist"})); err == nil {
+		t.Fatalf("expect error returned")
+	}
+}
+
+// 测试错误信息中的敏感值
+func TestMapperSecretError(t *testing.T) {
+	type Config struct {
+		Pin  int    `env:"PIN,secret123" secret:"true"`
+		Port int    `env:"PORT,abc"`
+		Key  Secret `env:"KEY,k3y" validate:"min=5"`
+	}
+	var c Config
+	err := MapFrom(&c, nil)
+	if err == nil {
+		t.Fatalf("expect error returned")
+	}
+	if strings.Contains(err.Error(), "secret123") || strings.Contains(err.Error(), "k3y") {
+		t.Fatalf("secret leaked: %s", err)
+	}
+	if !strings.Contains(err.Error(), `"abc"`) {
+		t.Fatalf("non-secret value should be kept: %s", err)
+	}
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 3 || errs[0].Value != mask || errs[2].Value != mask {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatalf("secret error should keep the error chain")
+	}
+	// 错误链中包含原始值的错误不能通过 errors.As 取出
+	var numErr *strconv.NumError
+	if errors.As(errs[0], &numErr) || !errors.As(errs[1], &numErr) {
+		t.Fatalf("secret should not be reachable through the error chain")
+	}
+
+	// 只替换完整的值，不会替换其他单词中的一部分
+	type Short struct {
+		Code int `env:"CODE,a" secret:"true"`
+	}
+	var short Short
+	err = MapFrom(&short, nil)
+	expect := `Short.Code (CODE="******"): setInt64: strconv.ParseInt: parsing "******": invalid syntax`
+	if err == nil || err.Error() != expect {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// slice、map 中解析失败的元素同样不能出现在错误信息中
+	type Elements struct {
+		Codes  []int          `env:"CODES" secret:"true"`
+		Limits map[string]int `env:"LIMITS" secret:"true" sep:";"`
+	}
+	var e Elements
+	err = MapFrom(&e, map[string]string{"CODES": "12, hunter2", "LIMITS": "a=1;b=hunter3"})
+	if err == nil || strings.Contains(err.Error(), "hunter") {
+		t.Fatalf("secret leaked: %v", err)
+	}
+
+	// secret 标签和 required 等标签一样解析，无效的值返回错误
+	type Invalid struct {
+		Pass string `env:"PASS" secret:"yes"`
+	}
+	var i Invalid
+	err = MapFrom(&i, map[string]string{"PASS": "hunter2"})
+	if err == nil || !strings.Contains(err.Error(), "invalid secret tag") || strings.Contains(err.Error(), "hunter2") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	type Parsed struct {
+		Pass int `env:"PASS" secret:"1"`
+	}
+	var p Parsed
+	if err := MapFrom(&p, map[string]string{"PASS": "hunter2"}); err == nil || strings.Contains(err.Error(), "hunter2") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSprint(t *testing.T) {
+	type DB struct {
+		Host     string
+		Password string `secret:"true"`
+	}
+	type Config struct {
+		Name    string
+		Token   Secret
+		Empty   Secret
+		DB      *DB
+		Replica []DB
+		Hosts   []string
+		private string
+	}
+	c := Config{
+		Name:    "pkg",
+		Token:   "token",
+		DB:      &DB{Host: "localhost", Password: "p@ss"},
+		Replica: []DB{{Host: "replica", Password: "p@ss"}},
+		Hosts:   []string{"a", "b"},
+		private: "private",
+	}
+	expect := "{Name:pkg Token:****** Empty: DB:&{Host:localhost Password:******} " +
+		"Replica:[{Host:replica Password:******}] Hosts:[a b]}"
+	if s := Sprint(c); s != expect {
+		t.Fatalf("unexpected result: %s", s)
+	}
+	if s := Sprint(&c); s != "&"+expect {
+		t.Fatalf("unexpected result: %s", s)
+	}
+	if s := Sprint(nil); s != "<nil>" {
+		t.Fatalf("unexpected result: %s", s)
+	}
+}
@@ -0,0 +1,409 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Source 配置的来源，Lookup 返回 key 对应的值，以及这个 key 是否存在
+type Source interface {
+	Lookup(key string) (string, bool)
+}
+
+// Lister 可以列出所有 key 的 Source，结构体切片需要通过扫描 key 找到 SERVERS_0_HOST 这种带下标的 key
+// 内置的 Source 都实现了这个接口
+type Lister interface {
+	Keys() []string
+}
+
+// Loader 按顺序从多个 Source 中读取配置灌入到结构体中
+//
+//	env, _ := config.DotEnvFile(".env")
+//	loader := &config.Loader{Sources: []config.Source{config.Env(), env}}
+//	err := loader.Load(&c)
+type Loader struct {
+	// Sources 按优先级从高到低排列，同一个 key 取第一个存在这个 key 的 Source 的值
+	Sources []Source
+	// Strict 为 true 时所有可导出字段都不允许为空，同 MustMapConfig
+	Strict bool
+	// Prefix 所有 key 的前缀，如 Prefix 为 APP_ 时，字段 Host 的 key 为 APP_HOST
+	Prefix string
+	// AutoPrefix 为 true 时，嵌套结构体中字段的 key 会加上父字段的 key 作为前缀，
+	// 如 Primary DB 中的字段 Host 的 key 为 PRIMARY_HOST
+	// 嵌套结构体字段也可以通过 prefix 标签指定前缀，如 `prefix:"REPLICA_"`，prefix 标签不受 AutoPrefix 影响
+	AutoPrefix bool
+	// AllowEmpty 为 true 时，设置为空的 key 使用空值而不是默认值，如 ROUTING_KEY= 会把字段设置为零值，
+	// 单个字段可以通过 allowEmpty 标签设置，优先级高于 AllowEmpty
+	AllowEmpty bool
+	// DisallowUnknown 为 true 时，Sources 中带有 Prefix 前缀但是没有被任何字段读取的 key 会作为错误返回，
+	// 如 APP_DB_PASWORD，错误信息中会给出相近的 key 作为建议
+	// Prefix 为空时不检查，只有实现了 Lister 的 Source 才会被检查
+	DisallowUnknown bool
+	// Audit 为 true 时，Load 成功之后通过 log 包输出每个配置项最终的值和来源，敏感字段的值会被替换为 ******
+	// 来源是 Source 的 Name() 方法的返回值（如 env、file:.env），没有实现时使用 Source 的类型，
+	// 使用默认值时为 default 或 default.<profile>
+	Audit bool
+	// Profile 当前的环境，如 dev、staging、prod，为空时从 Sources 中读取 ProfileKey 的值
+	// 设置之后字段的默认值优先使用 `default.<profile>` 标签，如 `default.prod:"..."`
+	Profile string
+	// ProfileKey 保存 profile 的完整 key，如 APP_ENV，不会加上 Prefix
+	ProfileKey string
+	// Profiles 每个 profile 额外的 Source，如 .env.prod，优先级低于 Sources，高于标签中的默认值
+	Profiles map[string][]Source
+}
+
+// Load 从 l.Sources 中读取配置灌入到 dest 中，dest 的要求和返回的错误同 MapConfig
+func (l *Loader) Load(dest interface{}) error {
+	return l.newMapper().mapper(dest)
+}
+
+// newMapper 根据 l 的配置创建一个 mapper
+func (l *Loader) newMapper() *mapper {
+	profile := l.profile()
+	m := newMapper(l.Strict, l.sources(profile)...)
+	m.profile = profile
+	m.prefix = l.Prefix
+	m.autoPrefix = l.AutoPrefix
+	m.allowEmpty = l.AllowEmpty
+	m.disallowUnknown = l.DisallowUnknown
+	m.audit = l.Audit
+	// ProfileKey 不对应任何字段，但是已经被 Loader 读取
+	if l.ProfileKey != "" {
+		m.markKnown(l.ProfileKey)
+	}
+	return m
+}
+
+// Load 按顺序从 sources 中读取配置灌入到 dest 中，允许字段值为空
+func Load(dest interface{}, sources ...Source) error {
+	return (&Loader{Sources: sources}).Load(dest)
+}
+
+// MustLoad 同 Load，但是所有可导出字段都不允许为空
+func MustLoad(dest interface{}, sources ...Source) error {
+	return (&Loader{Sources: sources, Strict: true}).Load(dest)
+}
+
+// MapFrom 同 MapConfig，但是从 values 中读取配置，不会读取或修改当前进程的环境变量，
+// 测试中可以代替 os.Setenv，和 t.Parallel() 一起使用
+func MapFrom(dest interface{}, values map[string]string) error {
+	return Load(dest, Map(values))
+}
+
+// MustMapFrom 同 MustMapConfig，但是从 values 中读取配置
+func MustMapFrom(dest interface{}, values map[string]string) error {
+	return MustLoad(dest, Map(values))
+}
+
+// lookup 依次从 sources 中查找 key
+func lookup(sources []Source, key string) (string, bool) {
+	val, _, ok := lookupSource(sources, key)
+	return val, ok
+}
+
+// lookupSource 同 lookup，同时返回 key 所在的 Source
+func lookupSource(sources []Source, key string) (string, Source, bool) {
+	for _, s := range sources {
+		if val, ok := s.Lookup(key); ok {
+			return val, s, true
+		}
+	}
+	return "", nil, false
+}
+
+// keys 返回 sources 中所有实现了 Lister 的 Source 的 key，已去重
+func keys(sources []Source) []string {
+	var (
+		result []string
+		seen   = make(map[string]struct{})
+	)
+	for _, s := range sources {
+		l, ok := s.(Lister)
+		if !ok {
+			continue
+		}
+		for _, key := range l.Keys() {
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+// envSource 从当前进程的环境变量读取配置
+type envSource struct{}
+
+// Env 返回从环境变量读取配置的 Source
+func Env() Source {
+	return envSource{}
+}
+
+// Lookup Source interface
+func (envSource) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+// Keys Lister interface
+func (envSource) Keys() []string {
+	environ := os.Environ()
+	result := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		if i := strings.Index(kv, "="); i > 0 {
+			result = append(result, kv[:i])
+		}
+	}
+	return result
+}
+
+// Name 用于 Loader.Audit 中显示配置的来源
+func (envSource) Name() string {
+	return "env"
+}
+
+// mapSource 从内存中的 map 读取配置
+type mapSource map[string]string
+
+// Map 返回从 m 中读取配置的 Source，常用于测试
+func Map(m map[string]string) Source {
+	return mapSource(m)
+}
+
+// Lookup Source interface
+func (m mapSource) Lookup(key string) (string, bool) {
+	val, ok := m[key]
+	return val, ok
+}
+
+// Keys Lister interface
+func (m mapSource) Keys() []string {
+	return mapKeys(m)
+}
+
+// Name 用于 Loader.Audit 中显示配置的来源
+func (m mapSource) Name() string {
+	return "map"
+}
+
+func mapKeys(m map[string]string) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	return result
+}
+
+// FileSource 从文件中读取的配置，文件在创建时读取并解析为 key-value 的形式，
+// 之后可以通过 Reload 重新读取
+type FileSource struct {
+	path  string
+	parse func([]byte) (map[string]string, error)
+
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+// DotEnvFile 读取 .env 格式的文件，每行一个 KEY=VALUE，支持：
+// 以 # 开头的注释行、export 前缀、单引号（原样保留）和双引号（支持 \n 等转义）包裹的值
+func DotEnvFile(path string) (*FileSource, error) {
+	return newFileSource(path, parseDotEnv)
+}
+
+// JSONFile 读取只有一层的 JSON 文件，值可以是字符串、数字、布尔值以及由它们组成的数组，
+// 数组会以 `,` 拼接为一个字符串
+func JSONFile(path string) (*FileSource, error) {
+	return newFileSource(path, parseJSON)
+}
+
+// YAMLFile 读取只有一层的 YAML 文件，值的要求同 JSONFile
+func YAMLFile(path string) (*FileSource, error) {
+	return newFileSource(path, parseYAML)
+}
+
+func newFileSource(path string, parse func([]byte) (map[string]string, error)) (*FileSource, error) {
+	f := &FileSource{path: path, parse: parse}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Lookup Source interface
+func (f *FileSource) Lookup(key string) (string, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	val, ok := f.values[key]
+	return val, ok
+}
+
+// Keys Lister interface
+func (f *FileSource) Keys() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return mapKeys(f.values)
+}
+
+// Path 返回文件路径
+func (f *FileSource) Path() string {
+	return f.path
+}
+
+// Name 用于 Loader.Audit 中显示配置的来源
+func (f *FileSource) Name() string {
+	return "file:" + f.path
+}
+
+// Reload 重新读取文件，如果读取或解析失败，保留之前的值
+func (f *FileSource) Reload() error {
+	commit, err := f.read()
+	if err != nil {
+		return err
+	}
+	commit()
+	return nil
+}
+
+// read 读取并解析文件，调用 commit 之后才替换当前的值，参考 reloader
+func (f *FileSource) read() (commit func(), err error) {
+	b, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return nil, wrapE("read config file", err)
+	}
+	values, err := f.parse(b)
+	if err != nil {
+		return nil, wrapE(fmt.Sprintf("parse config file %s", f.path), err)
+	}
+	return func() {
+		f.mu.Lock()
+		f.values = values
+		f.mu.Unlock()
+	}, nil
+}
+
+// parseDotEnv 解析 .env 格式的内容
+func parseDotEnv(b []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.Index(line, "=")
+		if i <= 0 {
+			return nil, newE("line %d: invalid format, expect KEY=VALUE", n)
+		}
+		key, val := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		switch {
+		case len(val) > 1 && val[0] == '"' && val[len(val)-1] == '"':
+			unquoted, err := strconv.Unquote(val)
+			if err != nil {
+				return nil, wrapE(fmt.Sprintf("line %d", n), err)
+			}
+			val = unquoted
+		case len(val) > 1 && val[0] == '\'' && val[len(val)-1] == '\'':
+			val = val[1 : len(val)-1]
+		default:
+			// 未被引号包裹的值，去掉行尾注释
+			if j := strings.Index(val, " #"); j >= 0 {
+				val = strings.TrimSpace(val[:j])
+			}
+		}
+		values[key] = val
+	}
+	return values, scanner.Err()
+}
+
+// parseJSON 解析只有一层的 JSON 内容
+func parseJSON(b []byte) (map[string]string, error) {
+	var raw map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(raw))
+	for key, val := range raw {
+		s, err := flatValue(val)
+		if err != nil {
+			return nil, wrapE(key, err)
+		}
+		values[key] = s
+	}
+	return values, nil
+}
+
+// parseYAML 解析只有一层的 YAML 内容
+func parseYAML(b []byte) (map[string]string, error) {
+	var raw map[string]yaml.Node
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(raw))
+	for key, node := range raw {
+		switch node.Kind {
+		case yaml.ScalarNode:
+			values[key] = scalarValue(node)
+		case yaml.SequenceNode:
+			items := make([]string, 0, len(node.Content))
+			for _, item := range node.Content {
+				if item.Kind != yaml.ScalarNode {
+					return nil, newE("%s: nested value is not supported", key)
+				}
+				items = append(items, scalarValue(*item))
+			}
+			values[key] = strings.Join(items, ",")
+		default:
+			return nil, newE("%s: nested value is not supported", key)
+		}
+	}
+	return values, nil
+}
+
+// scalarValue 返回 YAML 标量的值，~、null 和没有值的 key 和 JSON 的 null 一样为空
+func scalarValue(node yaml.Node) string {
+	if node.ShortTag() == "!!null" {
+		return ""
+	}
+	return node.Value
+}
+
+// flatValue 把 JSON 中的值转为字符串，不支持嵌套的对象和数组
+func flatValue(val interface{}) (string, error) {
+	switch v := val.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if _, ok := item.([]interface{}); ok {
+				return "", newE("nested value is not supported")
+			}
+			s, err := flatValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ","), nil
+	}
+	return "", newE("nested value is not supported")
+}
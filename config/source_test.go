@@ -0,0 +1,176 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return path
+}
+
+func TestDotEnvFile(t *testing.T) {
+	path := writeFile(t, ".env", `
+# comment
+HOST=localhost
+export PORT=3306
+NAME = "hello\nworld"
+RAW='a "b" #c'
+TRAIL=value # comment
+EMPTY=
+`)
+	f, err := DotEnvFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := map[string]string{
+		"HOST":  "localhost",
+		"PORT":  "3306",
+		"NAME":  "hello\nworld",
+		"RAW":   `a "b" #c`,
+		"TRAIL": "value",
+		"EMPTY": "",
+	}
+	if !reflect.DeepEqual(f.values, expect) {
+		t.Fatalf("unexpected values: %v", f.values)
+	}
+
+	if _, err := DotEnvFile(writeFile(t, ".env", "INVALID")); err == nil {
+		t.Fatalf("expect error returned")
+	}
+	if _, err := DotEnvFile(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expect not exist error, got: %v", err)
+	}
+}
+
+func TestJSONFile(t *testing.T) {
+	path := writeFile(t, "config.json", `{"HOST": "localhost", "PORT": 3306, "RATE": 0.5, "DEBUG": true, "HOSTS": ["a", "b"], "NULL": null}`)
+	f, err := JSONFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := map[string]string{
+		"HOST":  "localhost",
+		"PORT":  "3306",
+		"RATE":  "0.5",
+		"DEBUG": "true",
+		"HOSTS": "a,b",
+		"NULL":  "",
+	}
+	if !reflect.DeepEqual(f.values, expect) {
+		t.Fatalf("unexpected values: %v", f.values)
+	}
+
+	if _, err := JSONFile(writeFile(t, "config.json", `{"DB": {"HOST": "localhost"}}`)); err == nil {
+		t.Fatalf("expect error returned")
+	}
+}
+
+func TestYAMLFile(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+HOST: localhost
+PORT: 3306
+TIMEOUT: 5s
+HOSTS:
+  - a
+  - b
+TILDE: ~
+NONE: null
+EMPTY:
+QUOTED: "null"
+`)
+	f, err := YAMLFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := map[string]string{
+		"HOST":    "localhost",
+		"PORT":    "3306",
+		"TIMEOUT": "5s",
+		"HOSTS":   "a,b",
+		"TILDE":   "",
+		"NONE":    "",
+		"EMPTY":   "",
+		"QUOTED":  "null",
+	}
+	if !reflect.DeepEqual(f.values, expect) {
+		t.Fatalf("unexpected values: %v", f.values)
+	}
+
+	if _, err := YAMLFile(writeFile(t, "config.yaml", "DB:\n  HOST: localhost\n")); err == nil {
+		t.Fatalf("expect error returned")
+	}
+}
+
+// 测试多个 Source 的优先级
+func TestLoad(t *testing.T) {
+	type Config struct {
+		Host  string `env:"HOST,default"`
+		Port  int    `env:"PORT,80"`
+		Debug bool   `env:"DEBUG,false"`
+		Name  string
+	}
+	dotenv, err := DotEnvFile(writeFile(t, ".env", "HOST=file\nPORT=3306\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	high := Map(map[string]string{"HOST": "map"})
+
+	var c Config
+	if err := Load(&c, high, dotenv); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := Config{Host: "map", Port: 3306}
+	if !reflect.DeepEqual(c, expect) {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+
+	// strict 模式下 Name 不允许为空
+	if err := MustLoad(&c, high, dotenv); err == nil {
+		t.Fatalf("expect error returned")
+	}
+	loader := &Loader{Sources: []Source{Map(map[string]string{"NAME": "pkg"}), dotenv}, Strict: true}
+	if err := loader.Load(&c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Name != "pkg" || c.Host != "file" {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+}
+
+// 测试 FileSource 重新加载
+func TestFileSource_Reload(t *testing.T) {
+	path := writeFile(t, ".env", "HOST=a\n")
+	f, err := DotEnvFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ioutil.WriteFile(path, []byte("HOST=b\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.Reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if val, _ := f.Lookup("HOST"); val != "b" {
+		t.Fatalf("unexpected value: %s", val)
+	}
+
+	// 解析失败时保留之前的值
+	if err := ioutil.WriteFile(path, []byte("INVALID\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.Reload(); err == nil {
+		t.Fatalf("expect error returned")
+	}
+	if val, _ := f.Lookup("HOST"); val != "b" {
+		t.Fatalf("unexpected value: %s", val)
+	}
+}
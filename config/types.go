@@ -0,0 +1,118 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"time"
+)
+
+// 支持的标准库类型
+// 指针类型（如 *url.URL、*regexp.Regexp）会先经过 setPtr 创建出指向的值，再按下面的值类型处理，
+// time.Local 等 *time.Location 的值不能复制，只支持 *time.Location，直接设置指针
+var (
+	durationType    = reflect.TypeOf(time.Duration(0))
+	timeType        = reflect.TypeOf(time.Time{})
+	locationType    = reflect.TypeOf(time.Location{})
+	locationPtrType = reflect.TypeOf(&time.Location{})
+	ipType          = reflect.TypeOf(net.IP{})
+	urlType         = reflect.TypeOf(url.URL{})
+	regexpType      = reflect.TypeOf(regexp.Regexp{})
+)
+
+// isStdType 判断 t 是否是需要特殊处理的标准库类型
+func isStdType(t reflect.Type) bool {
+	switch t {
+	case durationType, timeType, locationType, ipType, urlType, regexpType:
+		return true
+	}
+	return false
+}
+
+// setStdType 处理标准库中的特殊类型，如果 v 的类型不需要特殊处理，handled 返回 false
+func (m *mapper) setStdType(v reflect.Value, value string, d *data) (handled bool, err error) {
+	switch v.Type() {
+	default:
+		return false, nil
+	case durationType:
+		return true, m.setDuration(v, value)
+	case timeType:
+		return true, m.setTime(v, value, d)
+	case locationPtrType:
+		return true, m.setLocation(v, value)
+	case locationType:
+		return true, newE("unsupported type: time.Location, use *time.Location instead")
+	case ipType:
+		return true, m.setIP(v, value)
+	case urlType:
+		return true, m.setURL(v, value)
+	case regexpType:
+		return true, m.setRegexp(v, value)
+	}
+}
+
+// setDuration 设置 time.Duration 类型，格式参考 time.ParseDuration，如 "5s"、"1h30m"
+func (m *mapper) setDuration(v reflect.Value, value string) error {
+	i, err := time.ParseDuration(value)
+	if err != nil {
+		return wrapE("setDuration", err)
+	}
+	v.SetInt(int64(i))
+	return nil
+}
+
+// setTime 设置 time.Time 类型，解析格式由 layout 标签指定，默认为 time.RFC3339
+func (m *mapper) setTime(v reflect.Value, value string, d *data) error {
+	layout := time.RFC3339
+	if d != nil && d.layout != "" {
+		layout = d.layout
+	}
+	i, err := time.Parse(layout, value)
+	if err != nil {
+		return wrapE("setTime", err)
+	}
+	v.Set(reflect.ValueOf(i))
+	return nil
+}
+
+// setLocation 设置 *time.Location 类型，格式参考 time.LoadLocation，如 "Asia/Shanghai"、"Local"
+// time.Local 的内容是第一次使用时才加载的，复制之后会变成没有名字的 UTC，所以直接设置指针
+func (m *mapper) setLocation(v reflect.Value, value string) error {
+	i, err := time.LoadLocation(value)
+	if err != nil {
+		return wrapE("setLocation", err)
+	}
+	v.Set(reflect.ValueOf(i))
+	return nil
+}
+
+// setIP 设置 net.IP 类型，支持 IPv4 和 IPv6
+func (m *mapper) setIP(v reflect.Value, value string) error {
+	i := net.ParseIP(value)
+	if i == nil {
+		return newE("setIP: invalid IP address: %s", value)
+	}
+	v.Set(reflect.ValueOf(i))
+	return nil
+}
+
+// setURL 设置 url.URL 类型
+func (m *mapper) setURL(v reflect.Value, value string) error {
+	i, err := url.Parse(value)
+	if err != nil {
+		return wrapE("setURL", err)
+	}
+	v.Set(reflect.ValueOf(i).Elem())
+	return nil
+}
+
+// setRegexp 设置 regexp.Regexp 类型
+func (m *mapper) setRegexp(v reflect.Value, value string) error {
+	i, err := regexp.Compile(value)
+	if err != nil {
+		return wrapE("setRegexp", err)
+	}
+	v.Set(reflect.ValueOf(i).Elem())
+	return nil
+}
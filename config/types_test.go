@@ -0,0 +1,131 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// 测试 time.Duration
+func TestMapperDuration(t *testing.T) {
+	type Config struct {
+		Timeout  time.Duration   `env:"TIMEOUT,5s"`
+		Retry    *time.Duration  `env:"RETRY,1m30s"`
+		Backoffs []time.Duration `env:"BACKOFFS,1s,2s,4s"`
+	}
+	var c Config
+	if err := MustMapFrom(&c, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Timeout != 5*time.Second {
+		t.Fatalf("unexpected timeout: %s", c.Timeout)
+	}
+	if *c.Retry != 90*time.Second {
+		t.Fatalf("unexpected retry: %s", *c.Retry)
+	}
+	if len(c.Backoffs) != 3 || c.Backoffs[2] != 4*time.Second {
+		t.Fatalf("unexpected backoffs: %v", c.Backoffs)
+	}
+
+	type Invalid struct {
+		Timeout time.Duration `env:"TIMEOUT,5"`
+	}
+	var i Invalid
+	if err := MapFrom(&i, nil); err == nil {
+		t.Fatalf("expect error returned")
+	}
+}
+
+// 测试 time.Time
+func TestMapperTime(t *testing.T) {
+	type Config struct {
+		Start time.Time  `env:"START,2021-03-01T08:00:00Z"`
+		Day   time.Time  `env:"DAY,2021-03-01" layout:"2006-01-02"`
+		End   *time.Time `env:"END,2021-03-02T08:00:00+08:00"`
+		Empty time.Time
+	}
+	var c Config
+	if err := MapFrom(&c, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !c.Start.Equal(time.Date(2021, 3, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected start: %s", c.Start)
+	}
+	if !c.Day.Equal(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected day: %s", c.Day)
+	}
+	if !c.End.Equal(time.Date(2021, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected end: %s", c.End)
+	}
+	if !c.Empty.IsZero() {
+		t.Fatalf("empty time should be ignored")
+	}
+
+	// time.Time 不应该被当作结构体忽略
+	type Strict struct {
+		Start time.Time
+	}
+	var s Strict
+	if err := MustMapFrom(&s, nil); err == nil {
+		t.Fatalf("expect error returned")
+	}
+}
+
+// 测试 net.IP、url.URL、regexp.Regexp、time.Location
+func TestMapperStdTypes(t *testing.T) {
+	type Config struct {
+		IP       net.IP         `env:"IP,127.0.0.1"`
+		IPs      []net.IP       `env:"IPS,10.0.0.1,::1"`
+		URL      *url.URL       `env:"URL,https://ningzi.club/path?q=1"`
+		Pattern  *regexp.Regexp `env:"PATTERN,^[a-z]+$"`
+		Location *time.Location `env:"LOCATION,UTC"`
+	}
+	var c Config
+	if err := MustMapFrom(&c, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !c.IP.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Fatalf("unexpected ip: %s", c.IP)
+	}
+	if len(c.IPs) != 2 || !c.IPs[1].Equal(net.IPv6loopback) {
+		t.Fatalf("unexpected ips: %v", c.IPs)
+	}
+	if c.URL.Host != "ningzi.club" || c.URL.Query().Get("q") != "1" {
+		t.Fatalf("unexpected url: %s", c.URL)
+	}
+	if !c.Pattern.MatchString("abc") || c.Pattern.MatchString("ABC") {
+		t.Fatalf("unexpected pattern: %s", c.Pattern)
+	}
+	if c.Location.String() != "UTC" {
+		t.Fatalf("unexpected location: %s", c.Location)
+	}
+	// Local 保持为 time.Local，不会复制成 UTC
+	if err := MapFrom(&c, map[string]string{"LOCATION": "Local"}); err != nil || c.Location != time.Local {
+		t.Fatalf("unexpected location: %v, %v", c.Location, err)
+	}
+
+	var cases = []interface{}{
+		&struct {
+			IP net.IP `env:"IP,256.0.0.1"`
+		}{},
+		&struct {
+			URL *url.URL `env:"URL,:/foo"`
+		}{},
+		&struct {
+			Pattern *regexp.Regexp `env:"PATTERN,[a-z"`
+		}{},
+		&struct {
+			Location *time.Location `env:"LOCATION,Mars/Olympus"`
+		}{},
+		&struct {
+			Location time.Location `env:"LOCATION,UTC"`
+		}{},
+	}
+	for i, c := range cases {
+		if err := MapFrom(c, nil); err == nil {
+			t.Fatalf("expect error returned, index: %d", i)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package config
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// format 标签支持的格式
+const (
+	tagFormat = "format"
+
+	formatBytes   = "bytes"   // 字节大小，如 64MiB、1GB，适用于整数字段
+	formatLiteral = "literal" // Go 的数字字面量，如 0x1F、0o755、0b101、1_000_000，适用于整数和浮点数字段
+	formatPercent = "percent" // 百分比，如 50% 解析为 0.5，适用于浮点数字段
+	formatJSON    = "json"    // JSON，使用 encoding/json 解析，适用于任意类型，参考 setJSON
+)
+
+// 字节大小的单位，K、M 等十进制单位以 1000 为倍数，Ki、Mi 等二进制单位以 1024 为倍数
+var byteUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"m":   1e6,
+	"mb":  1e6,
+	"g":   1e9,
+	"gb":  1e9,
+	"t":   1e12,
+	"tb":  1e12,
+	"p":   1e15,
+	"pb":  1e15,
+	"ki":  1 << 10,
+	"kib": 1 << 10,
+	"mi":  1 << 20,
+	"mib": 1 << 20,
+	"gi":  1 << 30,
+	"gib": 1 << 30,
+	"ti":  1 << 40,
+	"tib": 1 << 40,
+	"pi":  1 << 50,
+	"pib": 1 << 50,
+}
+
+// ByteSize 字节大小，可以写为 64MiB、1.5GB、1024 等形式，单位不区分大小写，
+// 不需要 format 标签
+type ByteSize uint64
+
+// 常用的字节大小
+const (
+	B ByteSize = 1 << (10 * iota)
+	KiB
+	MiB
+	GiB
+	TiB
+	PiB
+)
+
+// ParseByteSize 解析字节大小，如 64MiB、1GB、512k，没有单位时表示字节数
+func ParseByteSize(s string) (ByteSize, error) {
+	n, err := parseBytes(s)
+	return ByteSize(n), err
+}
+
+// UnmarshalText encoding.TextUnmarshaler
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	n, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = n
+	return nil
+}
+
+// MarshalText encoding.TextMarshaler
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// String fmt.Stringer，在能整除的单位中选择最短的写法，如 64MiB、1GB
+func (b ByteSize) String() string {
+	n := uint64(b)
+	result := strconv.FormatUint(n, 10) + "B"
+	if n == 0 {
+		return result
+	}
+	for _, u := range []struct {
+		name string
+		size uint64
+	}{
+		{"PiB", 1 << 50}, {"TiB", 1 << 40}, {"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10},
+		{"PB", 1e15}, {"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3},
+	} {
+		if n%u.size != 0 {
+			continue
+		}
+		if s := strconv.FormatUint(n/u.size, 10) + u.name; len(s) < len(result) {
+			result = s
+		}
+	}
+	return result
+}
+
+// parseBytes 解析字节大小，数字部分可以包含小数和 _，数字和单位之间可以有空格
+func parseBytes(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r == '.' || r == '_')
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	number, unit := strings.Replace(s[:i], "_", "", -1), strings.TrimSpace(s[i:])
+	size, ok := byteUnits[strings.ToLower(unit)]
+	if number == "" || !ok {
+		return 0, newE("invalid byte size: %q", s)
+	}
+	if n, err := strconv.ParseUint(number, 10, 64); err == nil {
+		if n > math.MaxUint64/size {
+			return 0, newE("byte size out of range: %q", s)
+		}
+		return n * size, nil
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, newE("invalid byte size: %q", s)
+	}
+	f *= float64(size)
+	if f >= math.MaxUint64 {
+		return 0, newE("byte size out of range: %q", s)
+	}
+	return uint64(f), nil
+}
+
+// isFormat 判断 format 标签的值是否有效
+func isFormat(format string) bool {
+	switch format {
+	case "", formatBytes, formatLiteral, formatPercent, formatJSON:
+		return true
+	}
+	return false
+}
+
+// setFormatted 按 format 标签解析数字，没有设置 format 标签时 handled 返回 false
+func (m *mapper) setFormatted(v reflect.Value, value string, d *data) (handled bool, err error) {
+	if d == nil || d.format == "" {
+		return false, nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true, m.setFormattedInt(v, value, d.format)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true, m.setFormattedUint(v, value, d.format)
+	case reflect.Float32, reflect.Float64:
+		return true, m.setFormattedFloat(v, value, d.format)
+	}
+	// slice、指针等交给 Kind 处理，元素会再次经过 setFieldValue
+	return false, nil
+}
+
+// setFormattedInt 按 bytes、literal 格式设置 int 类型
+func (m *mapper) setFormattedInt(v reflect.Value, value, format string) error {
+	var i int64
+	switch format {
+	case formatBytes:
+		n, err := parseBytes(value)
+		if err != nil {
+			return err
+		}
+		if n > math.MaxInt64 {
+			return newE("byte size out of range: %q", value)
+		}
+		i = int64(n)
+	case formatLiteral:
+		var err error
+		if i, err = strconv.ParseInt(value, 0, 64); err != nil {
+			return wrapE("setFormattedInt", err)
+		}
+	default:
+		return newE("format %s is not supported by %s", format, v.Type())
+	}
+	if v.OverflowInt(i) {
+		return newE("value out of range: %q", value)
+	}
+	v.SetInt(i)
+	return nil
+}
+
+// setFormattedUint 按 bytes、literal 格式设置 uint 类型
+func (m *mapper) setFormattedUint(v reflect.Value, value, format string) error {
+	var i uint64
+	var err error
+	switch format {
+	case formatBytes:
+		if i, err = parseBytes(value); err != nil {
+			return err
+		}
+	case formatLiteral:
+		if i, err = strconv.ParseUint(value, 0, 64); err != nil {
+			return wrapE("setFormattedUint", err)
+		}
+	default:
+		return newE("format %s is not supported by %s", format, v.Type())
+	}
+	if v.OverflowUint(i) {
+		return newE("value out of range: %q", value)
+	}
+	v.SetUint(i)
+	return nil
+}
+
+// setFormattedFloat 按 literal、percent 格式设置 float 类型
+// percent 格式中 50% 解析为 0.5，没有 % 时按原值解析，如 0.5
+func (m *mapper) setFormattedFloat(v reflect.Value, value, format string) error {
+	var scale float64 = 1
+	switch format {
+	case formatLiteral:
+		value = strings.Replace(value, "_", "", -1)
+	case formatPercent:
+		if strings.HasSuffix(value, "%") {
+			value, scale = strings.TrimSpace(strings.TrimSuffix(value, "%")), 100
+		}
+	default:
+		return newE("format %s is not supported by %s", format, v.Type())
+	}
+	f, err := strconv.ParseFloat(value, v.Type().Bits())
+	if err != nil {
+		return wrapE("setFormattedFloat", err)
+	}
+	f /= scale
+	if v.OverflowFloat(f) {
+		return newE("value out of range: %q", value)
+	}
+	v.SetFloat(f)
+	return nil
+}
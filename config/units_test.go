@@ -0,0 +1,123 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	var cases = []struct {
+		input  string
+		expect ByteSize
+		err    bool
+	}{
+		{input: "1024", expect: 1024},
+		{input: "64MiB", expect: 64 * MiB},
+		{input: "64mib", expect: 64 * MiB},
+		{input: "64Mi", expect: 64 * MiB},
+		{input: "1GB", expect: 1e9},
+		{input: "1.5 GiB", expect: 1536 * MiB},
+		{input: "512k", expect: 512000},
+		{input: "1_000B", expect: 1000},
+		{input: "16EiB", err: true},
+		{input: "MiB", err: true},
+		{input: "1.2.3MB", err: true},
+		{input: "-1MB", err: true},
+		{input: "20000000PiB", err: true},
+	}
+	for i, c := range cases {
+		size, err := ParseByteSize(c.input)
+		if (err != nil) != c.err || size != c.expect {
+			t.Fatalf("unexpected result: %d, %v, index: %d", size, err, i)
+		}
+	}
+}
+
+func TestByteSize_String(t *testing.T) {
+	for size, expect := range map[ByteSize]string{
+		0:            "0B",
+		100:          "100B",
+		64 * MiB:     "64MiB",
+		1536 * MiB:   "1536MiB",
+		1e9:          "1GB",
+		1001:         "1001B",
+		2 * KiB:      "2KiB",
+		PiB:          "1PiB",
+		3 * 1000000:  "3MB",
+		1500 * 1e3:   "1500KB",
+		1024 * 1e12:  "1024TB",
+		1024 * 1e15:  "1024PB",
+		10 * GiB:     "10GiB",
+		1<<10 + 1e3:  "2024B",
+		B + 1<<20*10: "10485761B",
+	} {
+		if size.String() != expect {
+			t.Fatalf("expect %s, got: %s", expect, size)
+		}
+		parsed, err := ParseByteSize(size.String())
+		if err != nil || parsed != size {
+			t.Fatalf("unexpected result: %d, %v", parsed, err)
+		}
+	}
+}
+
+func TestMapperFormat(t *testing.T) {
+	type Config struct {
+		Buffer   ByteSize  `env:"BUFFER,64MiB"`
+		Limit    *ByteSize `env:"LIMIT,1GB"`
+		MaxBody  int64     `env:"MAX_BODY,8KiB" format:"bytes"`
+		Sizes    []uint32  `env:"SIZES,1k;2Ki" sep:";" format:"bytes"`
+		Mode     uint32    `env:"MODE,0o755" format:"literal"`
+		Mask     int       `env:"MASK,0x1F" format:"literal"`
+		Flags    uint8     `env:"FLAGS,0b101" format:"literal"`
+		Count    int       `env:"COUNT,1_000_000" format:"literal"`
+		Rate     float64   `env:"RATE,1_000.5" format:"literal"`
+		Ratio    float64   `env:"RATIO,50%" format:"percent"`
+		Sample   float32   `env:"SAMPLE,0.25" format:"percent"`
+		Capacity ByteSize  `env:"CAPACITY,1GiB" validate:"max=2GiB"`
+		Plain    int       `env:"PLAIN,010"`
+		Percents []float64 `env:"PERCENTS,10%,20.5%" format:"percent"`
+	}
+	var c Config
+	if err := MapFrom(&c, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Buffer != 64*MiB || *c.Limit != 1e9 || c.MaxBody != 8192 || c.Sizes[0] != 1000 || c.Sizes[1] != 2048 {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+	if c.Mode != 0755 || c.Mask != 31 || c.Flags != 5 || c.Count != 1000000 || c.Rate != 1000.5 {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+	if c.Ratio != 0.5 || c.Sample != 0.25 || c.Capacity != GiB || c.Plain != 10 {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+	if len(c.Percents) != 2 || c.Percents[0] != 0.1 || c.Percents[1] != 0.205 {
+		t.Fatalf("unexpected value: %v", c.Percents)
+	}
+
+	var cases = []struct {
+		config interface{}
+		err    string
+	}{
+		{&struct {
+			Size int8 `env:"SIZE,1KiB" format:"bytes"`
+		}{}, "out of range"},
+		{&struct {
+			Size int `env:"SIZE,1KiB" format:"size"`
+		}{}, "invalid format tag"},
+		{&struct {
+			Size float64 `env:"SIZE,1KiB" format:"bytes"`
+		}{}, "not supported"},
+		{&struct {
+			Size int `env:"SIZE,50%" format:"percent"`
+		}{}, "not supported"},
+		{&struct {
+			Size ByteSize `env:"SIZE,3GiB" validate:"max=2GiB"`
+		}{}, "at most 2GiB"},
+	}
+	for i, c := range cases {
+		if err := MapFrom(c.config, nil); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Fatalf("expect error %q, got: %v, index: %d", c.err, err, i)
+		}
+	}
+}
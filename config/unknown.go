@@ -0,0 +1,95 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// markKnown 记录 mapper 读取过的 key，用于 Loader.DisallowUnknown 检查
+func (m *mapper) markKnown(keys ...string) {
+	if !m.disallowUnknown {
+		return
+	}
+	if m.known == nil {
+		m.known = make(map[string]struct{})
+	}
+	for _, key := range keys {
+		m.known[key] = struct{}{}
+	}
+}
+
+// checkUnknown 找出 sources 中带有 m.prefix 前缀，但是没有被任何字段读取的 key，
+// 每个 key 生成一个错误，如果有相近的 key，错误信息中会给出建议
+// 只有实现了 Lister 的 Source 才会被检查，m.prefix 为空时不检查
+func (m *mapper) checkUnknown() {
+	if !m.disallowUnknown || m.prefix == "" {
+		return
+	}
+	known := make([]string, 0, len(m.known))
+	for key := range m.known {
+		known = append(known, key)
+	}
+	sort.Strings(known)
+
+	var unknown []string
+	for _, key := range keys(m.sources) {
+		if _, ok := m.known[key]; ok || !strings.HasPrefix(key, m.prefix) {
+			continue
+		}
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		e := ConfigError{Key: key, Msg: "unknown key"}
+		if suggestion := suggest(key, known); suggestion != "" {
+			e.Msg += ", did you mean " + suggestion + "?"
+		}
+		m.errs = append(m.errs, e)
+	}
+}
+
+// suggest 返回 candidates 中和 key 编辑距离最小的 key，距离太大时返回空
+func suggest(key string, candidates []string) string {
+	// 允许的最大距离，key 越长允许的距离越大
+	limit := len(key) / 5
+	if limit < 2 {
+		limit = 2
+	}
+	best, distance := "", limit+1
+	for _, candidate := range candidates {
+		if d := levenshtein(key, candidate); d < distance {
+			best, distance = candidate, d
+		}
+	}
+	return best
+}
+
+// levenshtein 计算 a 和 b 之间的编辑距离
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(first int, rest ...int) int {
+	for _, i := range rest {
+		if i < first {
+			first = i
+		}
+	}
+	return first
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	var cases = []struct {
+		a, b   string
+		expect int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"APP_DB_PASWORD", "APP_DB_PASSWORD", 1},
+		{"APP_DB_HSOT", "APP_DB_HOST", 2},
+		{"kitten", "sitting", 3},
+	}
+	for _, c := range cases {
+		if d := levenshtein(c.a, c.b); d != c.expect {
+			t.Fatalf("expect %d, got: %d, %s -> %s", c.expect, d, c.a, c.b)
+		}
+	}
+}
+
+func TestLoaderDisallowUnknown(t *testing.T) {
+	type Config struct {
+		DB struct {
+			Host     string `env:"HOST,localhost"`
+			Password string `env:"PASSWORD" secret:"true"`
+		} `env:"DB"`
+		Addr    string `env:"MQ_ADDR|RABBITMQ_URL"`
+		URL     string `env:"URL,http://${APP_DOMAIN}/"`
+		Servers []struct {
+			Port int `env:"PORT"`
+		}
+	}
+	source := Map(map[string]string{
+		"APP_DB_HOST":          "db",
+		"APP_DB_PASWORD":       "p@ss",
+		"APP_DB_PASSWORD_FILE": writeFile(t, "password", "p@ss"),
+		"APP_RABBITMQ_URL":     "amqp://",
+		"APP_DOMAIN":           "example.com",
+		"APP_SERVERS_0_PORT":   "80",
+		"APP_SERVERS_1_PROT":   "81",
+		"APP_UNRELATED":        "1",
+		"HOME":                 "/root",
+	})
+	var c Config
+	loader := &Loader{Sources: []Source{source}, Prefix: "APP_", AutoPrefix: true, DisallowUnknown: true}
+	err := loader.Load(&c)
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 3 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := []string{
+		"APP_DB_PASWORD: unknown key, did you mean APP_DB_PASSWORD?",
+		"APP_SERVERS_1_PROT: unknown key, did you mean APP_SERVERS_1_PORT?",
+		"APP_UNRELATED: unknown key",
+	}
+	for i, e := range errs {
+		if e.Error() != expect[i] {
+			t.Fatalf("unexpected error: %s", e)
+		}
+	}
+	// 未知 key 不影响其他字段
+	if c.DB.Host != "db" || c.DB.Password != "p@ss" || c.Addr != "amqp://" || c.URL != "http://example.com/" {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+
+	// 默认不检查
+	loader.DisallowUnknown = false
+	if err := loader.Load(&c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
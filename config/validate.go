@@ -0,0 +1,299 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const tagValidate = "validate"
+
+// Validator 结构体（或结构体指针）实现这个接口时，在结构体所有字段赋值并且校验通过之后会调用 Validate，
+// 用于 validate 标签无法表达的校验，返回的错误会以 ConfigError 的形式返回
+type Validator interface {
+	Validate() error
+}
+
+// rule validate 标签中的一条规则，validate 标签的格式为 `validate:"rule1,rule2=param"`
+//
+//	min=N、max=N 数值类型比较大小（包括 time.Duration、time.Time），string、slice、array、map 比较长度
+//	oneof=a|b|c  值必须是其中之一
+//	regex=^\w+$  值必须匹配正则表达式，regex 必须是最后一条规则，之后的内容都是正则表达式
+//	url          值必须是包含 scheme 和 host 的 url
+//	hostport     值必须是 host:port 的形式
+//	gt=F、lt=F   值必须大于（小于）F，F 可以是同一个结构体中的其他字段名，也可以是一个值
+//
+// 没有设置值的可选字段不做校验
+type rule struct {
+	name  string
+	param string
+	re    *regexp.Regexp // regex 规则编译后的正则表达式
+}
+
+// parseRules 解析 validate 标签
+func parseRules(tag string) ([]rule, error) {
+	if tag == "" {
+		return nil, nil
+	}
+	var rules []rule
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "regex=") {
+			part, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			part, tag = tag[:i], tag[i+1:]
+		} else {
+			part, tag = tag, ""
+		}
+		r := rule{name: part}
+		if i := strings.Index(part, "="); i >= 0 {
+			r.name, r.param = part[:i], part[i+1:]
+		}
+		switch r.name {
+		default:
+			return nil, newE("unknown validate rule: %s", r.name)
+		case "url", "hostport":
+		case "min", "max", "oneof", "gt", "lt":
+			if r.param == "" {
+				return nil, newE("validate rule %s requires a parameter", r.name)
+			}
+		case "regex":
+			re, err := regexp.Compile(r.param)
+			if err != nil {
+				return nil, wrapE("validate rule regex", err)
+			}
+			r.re = re
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// checkRules 检查规则是否适用于字段的类型，min、max、gt、lt 只能用于可以比较大小的类型
+func checkRules(rules []rule, t reflect.Type) error {
+	t = indirectType(t)
+	for _, r := range rules {
+		switch r.name {
+		case "min", "max":
+			switch t.Kind() {
+			case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+				continue
+			}
+		case "gt", "lt":
+		default:
+			continue
+		}
+		if !isOrdered(t) {
+			return newE("validate rule %s is not supported by %s", r.name, t)
+		}
+	}
+	return nil
+}
+
+// isOrdered 判断 t 是否可以比较大小，参考 compare
+func isOrdered(t reflect.Type) bool {
+	if t == timeType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return true
+	}
+	return false
+}
+
+// validate 校验 parent 结构体中 d 对应的字段
+func (m *mapper) validate(parent reflect.Value, d *data) error {
+	v := indirect(parent.FieldByIndex(d.typ.Index))
+	// nil 指针无法校验
+	if !v.IsValid() {
+		return nil
+	}
+	for _, r := range d.rules {
+		if err := m.check(parent, v, r, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check 校验一条规则
+func (m *mapper) check(parent, v reflect.Value, r rule, d *data) error {
+	switch r.name {
+	case "min", "max":
+		cmp, err := m.compareBound(v, r.param, d, true)
+		if err != nil {
+			return err
+		}
+		if r.name == "min" && cmp < 0 {
+			return newE("validate: must be at least %s", r.param)
+		}
+		if r.name == "max" && cmp > 0 {
+			return newE("validate: must be at most %s", r.param)
+		}
+	case "gt", "lt":
+		var (
+			cmp int
+			err error
+		)
+		if sibling := parent.FieldByName(r.param); sibling.IsValid() {
+			cmp, err = compare(v, indirect(sibling))
+		} else {
+			cmp, err = m.compareBound(v, r.param, d, false)
+		}
+		if err != nil {
+			return err
+		}
+		if r.name == "gt" && cmp <= 0 {
+			return newE("validate: must be greater than %s", r.param)
+		}
+		if r.name == "lt" && cmp >= 0 {
+			return newE("validate: must be less than %s", r.param)
+		}
+	case "oneof":
+		s := toString(v)
+		for _, option := range strings.Split(r.param, "|") {
+			if s == option {
+				return nil
+			}
+		}
+		return newE("validate: must be one of [%s]", strings.Replace(r.param, "|", " ", -1))
+	case "regex":
+		if !r.re.MatchString(toString(v)) {
+			return newE("validate: must match %s", r.param)
+		}
+	case "url":
+		u, ok := v.Interface().(url.URL)
+		if !ok {
+			p, err := url.Parse(toString(v))
+			if err != nil {
+				return wrapE("validate: must be a valid url", err)
+			}
+			u = *p
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return newE("validate: must be a valid url")
+		}
+	case "hostport":
+		_, port, err := net.SplitHostPort(toString(v))
+		if err != nil {
+			return wrapE("validate: must be host:port", err)
+		}
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return newE("validate: invalid port %s", port)
+		}
+	}
+	return nil
+}
+
+// compareBound 比较 v 和 param，param 按照 v 的类型解析
+// length 为 true 时，string、slice、array、map 比较的是长度
+func (m *mapper) compareBound(v reflect.Value, param string, d *data, length bool) (int, error) {
+	if length {
+		switch v.Kind() {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				return 0, wrapE("validate: invalid length", err)
+			}
+			return compare(reflect.ValueOf(v.Len()), reflect.ValueOf(n))
+		}
+	}
+	bound := reflect.New(v.Type()).Elem()
+	if err := m.setFieldValue(bound, param, d); err != nil {
+		return 0, wrapE("validate: invalid parameter", err)
+	}
+	return compare(v, bound)
+}
+
+// compare 比较两个值的大小，a < b 返回 -1，a == b 返回 0，a > b 返回 1
+func compare(a, b reflect.Value) (int, error) {
+	if !a.IsValid() || !b.IsValid() {
+		return 0, newE("validate: cannot compare nil value")
+	}
+	if a.Type() == timeType && b.Type() == timeType {
+		x, y := a.Interface().(time.Time), b.Interface().(time.Time)
+		return sign(x.Before(y), x.After(y)), nil
+	}
+	switch {
+	case isInt(a) && isInt(b):
+		return sign(a.Int() < b.Int(), a.Int() > b.Int()), nil
+	case isUint(a) && isUint(b):
+		return sign(a.Uint() < b.Uint(), a.Uint() > b.Uint()), nil
+	case isFloat(a) && isFloat(b):
+		return sign(a.Float() < b.Float(), a.Float() > b.Float()), nil
+	case a.Kind() == reflect.String && b.Kind() == reflect.String:
+		return strings.Compare(a.String(), b.String()), nil
+	}
+	return 0, newE("validate: cannot compare %s with %s", a.Type(), b.Type())
+}
+
+// sign 根据比较结果返回 -1、0、1
+func sign(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+func isInt(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isUint(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func isFloat(v reflect.Value) bool {
+	return v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64
+}
+
+// toString 返回 v 的字符串形式，用于 oneof、regex 等规则
+func toString(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// indirect 返回指针指向的值，不会像 behind 一样为 nil 指针创建值，nil 指针返回零值 reflect.Value
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// callValidator 如果 v 实现了 Validator，调用 Validate
+func (m *mapper) callValidator(v reflect.Value) error {
+	if v.CanAddr() {
+		if validator, ok := v.Addr().Interface().(Validator); ok {
+			return validator.Validate()
+		}
+	}
+	if validator, ok := v.Interface().(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
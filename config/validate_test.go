@@ -0,0 +1,153 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type validated struct {
+	Min int `env:"MIN,1"`
+	Max int `env:"MAX,2"`
+}
+
+func (v validated) Validate() error {
+	if v.Min >= v.Max {
+		return errors.New("min must be less than max")
+	}
+	return nil
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := parseRules("min=1,oneof=a|b,url,regex=^a,b$")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 4 || rules[3].param != "^a,b$" || rules[1].param != "a|b" {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+
+	for _, tag := range []string{"unknown", "min", "regex=[a"} {
+		if _, err := parseRules(tag); err == nil {
+			t.Fatalf("expect error returned, tag: %s", tag)
+		}
+	}
+}
+
+func TestMapperValidate(t *testing.T) {
+	type Config struct {
+		Port     int           `env:"PORT,8080" validate:"min=1,max=65535"`
+		Name     string        `env:"NAME,pkg" validate:"min=2,max=8,regex=^[a-z]+$"`
+		Hosts    []string      `env:"HOSTS,a,b" validate:"min=1"`
+		Kind     string        `env:"KIND,topic" validate:"oneof=topic|fanout|direct|headers"`
+		Endpoint string        `env:"ENDPOINT,https://ningzi.club" validate:"url"`
+		Addr     string        `env:"ADDR,localhost:5672" validate:"hostport"`
+		Timeout  time.Duration `env:"TIMEOUT,5s" validate:"min=1s,lt=Deadline"`
+		Deadline time.Duration `env:"DEADLINE,10s"`
+		Ratio    float64       `env:"RATIO,0.5" validate:"gt=0,lt=1"`
+		Nested   validated
+	}
+	var c Config
+	if err := MustMapFrom(&c, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var cases = []struct {
+		input interface{}
+		msg   string
+	}{
+		{&struct {
+			Port int `env:"PORT,0" validate:"min=1"`
+		}{}, "at least 1"},
+		{&struct {
+			Port uint16 `env:"PORT,80" validate:"max=79"`
+		}{}, "at most 79"},
+		{&struct {
+			Name string `env:"NAME,abc" validate:"max=2"`
+		}{}, "at most 2"},
+		{&struct {
+			Hosts []string `env:"HOSTS,a" validate:"min=2"`
+		}{}, "at least 2"},
+		{&struct {
+			Kind string `env:"KIND,x" validate:"oneof=topic|fanout"`
+		}{}, "one of [topic fanout]"},
+		{&struct {
+			Name string `env:"NAME,ABC" validate:"regex=^[a-z]+$"`
+		}{}, "must match"},
+		{&struct {
+			Endpoint string `env:"ENDPOINT,ningzi.club" validate:"url"`
+		}{}, "valid url"},
+		{&struct {
+			Addr string `env:"ADDR,localhost" validate:"hostport"`
+		}{}, "host:port"},
+		{&struct {
+			Addr string `env:"ADDR,localhost:99999" validate:"hostport"`
+		}{}, "invalid port"},
+		{&struct {
+			Timeout  time.Duration `env:"TIMEOUT,10s" validate:"lt=Deadline"`
+			Deadline time.Duration `env:"DEADLINE,5s"`
+		}{}, "less than Deadline"},
+		{&struct {
+			Start time.Time `env:"START,2021-03-02T00:00:00Z"`
+			End   time.Time `env:"END,2021-03-01T00:00:00Z" validate:"gt=Start"`
+		}{}, "greater than Start"},
+		{&struct {
+			Nested validated `env:"-"`
+			Inner  struct {
+				validated
+			}
+		}{}, "min must be less than max"},
+		{&struct {
+			Port int `env:"PORT,1" validate:"unknown"`
+		}{}, "unknown validate rule"},
+		{&struct {
+			Inner struct{ Host string } `validate:"min=1"`
+		}{}, "validate rule min is not supported by struct"},
+		{&struct {
+			Hosts []string `env:"HOSTS,a" validate:"gt=1"`
+		}{}, "validate rule gt is not supported by []string"},
+	}
+	for i, c := range cases {
+		err := MapFrom(c.input, nil)
+		if err == nil || !strings.Contains(err.Error(), c.msg) {
+			t.Fatalf("expect error contains %q, got: %v, index: %d", c.msg, err, i)
+		}
+		var e ConfigError
+		if !errors.As(err, &e) {
+			t.Fatalf("expect ConfigError, got: %T", err)
+		}
+	}
+}
+
+// 字段赋值失败时不会再校验，也不会调用 Validate
+func TestMapperValidateSkip(t *testing.T) {
+	type Config struct {
+		validated
+		Port int `env:"PORT,abc" validate:"min=1"`
+	}
+	var c Config
+	err := MapFrom(&c, nil)
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("expect 1 error, got: %v", err)
+	}
+}
+
+// 没有设置的可选字段不做校验
+func TestMapperValidateUnset(t *testing.T) {
+	type Server struct {
+		Host string `env:"HOST"`
+	}
+	type Config struct {
+		Endpoint string   `env:"ENDPOINT" validate:"url"`
+		Servers  []Server `env:"SERVERS" validate:"min=1"`
+	}
+	var c Config
+	if err := MapFrom(&c, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := MapFrom(&c, map[string]string{"ENDPOINT": "abc"}); err == nil || !strings.Contains(err.Error(), "url") {
+		t.Fatalf("expect url error, got: %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/NingziSlay/pkg/log"
+)
+
+// reloader 可以重新加载的 Source，如 FileSource
+// read 只读取和解析，不修改 Source，所有 Source 都读取成功之后再调用 commit 一起替换，
+// 避免一部分 Source 已经更新，另一部分读取失败
+type reloader interface {
+	read() (commit func(), err error)
+}
+
+// pather 来自文件的 Source，Watcher 会定期检查文件是否有变化
+type pather interface {
+	Path() string
+}
+
+// Watcher 在文件变化或收到 SIGHUP 信号时重新读取配置，并通知订阅者
+// 每次重新读取都会创建一个新的结构体，读取成功后原子地替换当前的配置，
+// 读取失败时保留之前的配置，并把错误通知给 OnError 注册的方法
+//
+//	w, err := config.NewWatcher(&config.Loader{Sources: sources}, &c)
+//	w.OnChange(func(old, new interface{}) {
+//		log.Printf("config changed: %+v", new.(*Config))
+//	})
+//	w.Start()
+//	defer w.Stop()
+//
+// 需要读取配置的地方应该通过 Current 获取最新的配置，而不是一直持有 NewWatcher 时传入的 dest
+type Watcher struct {
+	// Interval 检查文件变化的间隔，默认为 5s，需要在 Start 之前设置
+	Interval time.Duration
+
+	loader  *Loader
+	typ     reflect.Type // dest 指向的结构体类型
+	current atomic.Value // 当前配置，类型同 dest
+
+	reloadMu sync.Mutex // 保证同一时间只有一个 Reload
+	mu       sync.RWMutex
+	onChange []func(old, new interface{})
+	onError  []func(error)
+
+	quit  chan struct{}
+	once  sync.Once
+	start sync.Once // 保证只启动一次
+}
+
+// NewWatcher 使用 loader 读取一次配置灌入到 dest 中，并返回一个 Watcher
+// dest 的要求同 MapConfig，如果第一次读取失败，直接返回错误
+func NewWatcher(loader *Loader, dest interface{}) (*Watcher, error) {
+	if err := loader.Load(dest); err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		Interval: time.Second * 5,
+		loader:   loader,
+		typ:      reflect.TypeOf(dest).Elem(),
+		quit:     make(chan struct{}),
+	}
+	w.current.Store(dest)
+	return w, nil
+}
+
+// Current 返回当前的配置，类型同 NewWatcher 时传入的 dest
+func (w *Watcher) Current() interface{} {
+	return w.current.Load()
+}
+
+// OnChange 注册配置变化时的回调，old 和 new 的类型同 NewWatcher 时传入的 dest
+// 配置重新读取之后没有变化时不会调用
+func (w *Watcher) OnChange(fn func(old, new interface{})) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onChange = append(w.onChange, fn)
+}
+
+// OnError 注册重新读取配置失败时的回调
+func (w *Watcher) OnError(fn func(error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = append(w.onError, fn)
+}
+
+// Reload 重新读取所有 Source 并灌入到一个新的结构体中，成功后替换当前的配置
+// 如果失败，保留之前的配置，并返回错误
+func (w *Watcher) Reload() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	var commits []func()
+	for _, s := range w.loader.allSources() {
+		if r, ok := s.(reloader); ok {
+			commit, err := r.read()
+			if err != nil {
+				w.notifyError(err)
+				return err
+			}
+			commits = append(commits, commit)
+		}
+	}
+	for _, commit := range commits {
+		commit()
+	}
+
+	dest := reflect.New(w.typ).Interface()
+	if err := w.loader.Load(dest); err != nil {
+		w.notifyError(err)
+		return err
+	}
+
+	old := w.current.Load()
+	if reflect.DeepEqual(old, dest) {
+		return nil
+	}
+	w.current.Store(dest)
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, fn := range w.onChange {
+		fn(old, dest)
+	}
+	return nil
+}
+
+func (w *Watcher) notifyError(err error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, fn := range w.onError {
+		fn(err)
+	}
+}
+
+// Start 开始监听文件变化和 SIGHUP 信号，不会阻塞，重复调用不会再次启动
+// 重新读取失败时，下一次检查会再次尝试，直到读取成功
+func (w *Watcher) Start() {
+	w.start.Do(w.run)
+}
+
+func (w *Watcher) run() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	interval := w.Interval
+	if interval <= 0 {
+		interval = time.Second * 5
+	}
+	ticker := time.NewTicker(interval)
+	stats := w.stat()
+
+	go func() {
+		defer ticker.Stop()
+		defer signal.Stop(signals)
+		logger := log.GetLogger()
+		for {
+			select {
+			case <-w.quit:
+				return
+			case <-signals:
+				logger.Info().Msg("config watcher - SIGHUP received, reload")
+			case <-ticker.C:
+				if reflect.DeepEqual(w.stat(), stats) {
+					continue
+				}
+				logger.Info().Msg("config watcher - file changed, reload")
+			}
+			// 读取成功之后才更新文件状态，失败时下一次检查会重试
+			current := w.stat()
+			if err := w.Reload(); err != nil {
+				logger.Warn().Err(err).Msg("config watcher - reload failed, keep the previous config")
+				continue
+			}
+			stats = current
+		}
+	}()
+}
+
+// Stop 停止监听，可以重复调用
+func (w *Watcher) Stop() {
+	w.once.Do(func() {
+		close(w.quit)
+	})
+}
+
+// fileStat 用于判断文件是否发生变化
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+// stat 返回所有文件 Source 的状态，文件不存在时状态为零值
+func (w *Watcher) stat() map[string]fileStat {
+	stats := make(map[string]fileStat)
+	for _, s := range w.loader.allSources() {
+		p, ok := s.(pather)
+		if !ok {
+			continue
+		}
+		var st fileStat
+		if info, err := os.Stat(p.Path()); err == nil {
+			st = fileStat{modTime: info.ModTime(), size: info.Size()}
+		}
+		stats[p.Path()] = st
+	}
+	return stats
+}
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatcher_Reload(t *testing.T) {
+	type Config struct {
+		Level string `env:"LEVEL,info"`
+		Rate  int    `env:"RATE,10"`
+	}
+	path := writeFile(t, ".env", "LEVEL=debug\n")
+	dotenv, err := DotEnvFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var c Config
+	w, err := NewWatcher(&Loader{Sources: []Source{dotenv}}, &c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Level != "debug" || w.Current().(*Config) != &c {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+
+	var changes, failures int
+	var old, new *Config
+	w.OnChange(func(o, n interface{}) {
+		changes++
+		old, new = o.(*Config), n.(*Config)
+	})
+	w.OnError(func(error) {
+		failures++
+	})
+
+	// 没有变化不会通知
+	if err := w.Reload(); err != nil || changes != 0 {
+		t.Fatalf("unexpected result: %v, %d", err, changes)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("LEVEL=warn\nRATE=20\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if changes != 1 || old.Level != "debug" || new.Level != "warn" || new.Rate != 20 {
+		t.Fatalf("unexpected change: %d, %+v, %+v", changes, old, new)
+	}
+	if w.Current().(*Config) != new {
+		t.Fatalf("current config should be replaced")
+	}
+
+	// 读取失败时保留之前的配置
+	if err := ioutil.WriteFile(path, []byte("RATE=abc\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Reload(); err == nil {
+		t.Fatalf("expect error returned")
+	}
+	if failures != 1 || changes != 1 || w.Current().(*Config) != new {
+		t.Fatalf("previous config should be kept")
+	}
+}
+
+func TestWatcher_Start(t *testing.T) {
+	type Config struct {
+		Level string `env:"LEVEL,info"`
+	}
+	path := writeFile(t, ".env", "LEVEL=debug\n")
+	dotenv, err := DotEnvFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var c Config
+	w, err := NewWatcher(&Loader{Sources: []Source{dotenv}}, &c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	changed := make(chan *Config, 1)
+	w.OnChange(func(_, n interface{}) {
+		changed <- n.(*Config)
+	})
+	w.Interval = time.Millisecond * 10
+	w.Start()
+	defer w.Stop()
+
+	if err := ioutil.WriteFile(path, []byte("LEVEL=error\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 保证修改时间发生变化
+	future := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case n := <-changed:
+		if n.Level != "error" {
+			t.Fatalf("unexpected value: %+v", n)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatalf("watcher should reload after file changed")
+	}
+	w.Stop()
+}
+
+// 所有文件都读取成功之后才一起替换，一个文件失败时其他文件保持不变
+func TestWatcher_ReloadAtomic(t *testing.T) {
+	type Config struct {
+		Level string `env:"LEVEL,info"`
+		Rate  int    `env:"RATE,10"`
+	}
+	dotenv, err := DotEnvFile(writeFile(t, ".env", "LEVEL=debug\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	jsonPath := writeFile(t, "config.json", `{"RATE": 20}`)
+	jsonFile, err := JSONFile(jsonPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var c Config
+	w, err := NewWatcher(&Loader{Sources: []Source{dotenv, jsonFile}}, &c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := ioutil.WriteFile(dotenv.Path(), []byte("LEVEL=warn\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ioutil.WriteFile(jsonPath, []byte(`{"RATE": `), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Reload(); err == nil {
+		t.Fatalf("expect error returned")
+	}
+	if val, _ := dotenv.Lookup("LEVEL"); val != "debug" {
+		t.Fatalf("source should not be replaced: %s", val)
+	}
+}
+
+// funcSource 用于测试不是来自文件的 Source
+type funcSource func(key string) (string, bool)
+
+func (f funcSource) Lookup(key string) (string, bool) {
+	return f(key)
+}
+
+// 重新读取失败之后，文件没有再次变化也会重试
+func TestWatcher_StartRetry(t *testing.T) {
+	type Config struct {
+		Level string `env:"LEVEL,info"`
+		Rate  int    `env:"RATE,10"`
+	}
+	path := writeFile(t, ".env", "LEVEL=debug\n")
+	dotenv, err := DotEnvFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var (
+		mu   sync.Mutex
+		rate = "10"
+	)
+	env := funcSource(func(key string) (string, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		return rate, key == "RATE"
+	})
+	var c Config
+	w, err := NewWatcher(&Loader{Sources: []Source{dotenv, env}}, &c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	changed := make(chan *Config, 1)
+	failed := make(chan error, 1)
+	w.OnChange(func(_, n interface{}) {
+		changed <- n.(*Config)
+	})
+	w.OnError(func(err error) {
+		select {
+		case failed <- err:
+		default:
+		}
+	})
+	w.Interval = time.Millisecond * 10
+	w.Start()
+	w.Start()
+	defer w.Stop()
+
+	mu.Lock()
+	rate = "abc"
+	mu.Unlock()
+	if err := ioutil.WriteFile(path, []byte("LEVEL=error\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	future := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case <-failed:
+	case <-time.After(time.Second * 2):
+		t.Fatalf("reload should fail")
+	}
+
+	mu.Lock()
+	rate = "20"
+	mu.Unlock()
+	select {
+	case n := <-changed:
+		if n.Level != "error" || n.Rate != 20 {
+			t.Fatalf("unexpected value: %+v", n)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatalf("watcher should retry after reload failed")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/NingziSlay/pkg/log"
 	"github.com/rs/zerolog"
 	"github.com/streadway/amqp"
+	"time"
 )
 
 type ExchangeKind string
@@ -20,15 +21,23 @@ type Config struct {
 	Exchange      string
 	ExchangeType  ExchangeKind // topic, direct, etc
 	Queue         string
-	RoutingKey    string
+	RoutingKey    string `allowEmpty:"true"` // 可以通过 ROUTING_KEY= 设置为空
 	ConsumerTag   string
 	PrefetchCount int
 	PrefetchSize  int
 	ExchangeArgs  amqp.Table
 	QueueArgs     amqp.Table
 	QueueBindArgs amqp.Table
+
+	// Confirm 开启 publisher confirm，Producer.Publish 会等待 broker 确认之后再返回
+	Confirm bool `env:"CONFIRM,false"`
+	// ConfirmTimeout 等待 broker 确认的超时时间，小于等于 0 时使用 defaultConfirmTimeout
+	ConfirmTimeout time.Duration `env:"CONFIRM_TIMEOUT,5s"`
 }
 
+// defaultConfirmTimeout 没有设置 Config.ConfirmTimeout 时等待 broker 确认的超时时间
+const defaultConfirmTimeout = 5 * time.Second
+
 type mq struct {
 	conn    *amqp.Connection
 	channel *amqp.Channel
@@ -0,0 +1,204 @@
+package mq
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/streadway/amqp"
+	"sync"
+)
+
+var (
+	// ErrConfirmDisabled 没有开启 Config.Confirm 时调用 PublishWithConfirm
+	ErrConfirmDisabled = errors.New("rabbitmq producer - confirm mode is not enabled")
+	// ErrConfirmClosed 收到确认之前 channel 已经关闭，消息是否被 broker 接收未知，需要重新发送
+	ErrConfirmClosed = errors.New("rabbitmq producer - channel closed before confirm")
+)
+
+// NackError broker 拒绝了消息（basic.nack），消息没有被接收，需要重新发送
+type NackError struct {
+	DeliveryTag uint64
+}
+
+func (e *NackError) Error() string {
+	return fmt.Sprintf("rabbitmq producer - message %d nacked by broker", e.DeliveryTag)
+}
+
+// ReturnError 消息没有匹配到任何队列，被 broker 退回（basic.return）
+// broker 退回消息之后仍然会 ack，但是消息已经被丢弃，需要检查 exchange、routing key 和队列的绑定
+type ReturnError struct {
+	DeliveryTag uint64
+	ReplyCode   uint16
+	ReplyText   string
+	Exchange    string
+	RoutingKey  string
+}
+
+func (e *ReturnError) Error() string {
+	return fmt.Sprintf("rabbitmq producer - message %d returned by broker: %d %s (exchange %q, routing key %q)",
+		e.DeliveryTag, e.ReplyCode, e.ReplyText, e.Exchange, e.RoutingKey)
+}
+
+// confirms 记录等待 broker 确认的消息
+// channel 进入 confirm 模式之后，每条消息的 delivery tag 从 1 开始依次递增，
+// broker 的 ack、nack 按 delivery tag 分发给等待的 publisher
+type confirms struct {
+	send    sync.Mutex // 保证分配 delivery tag 和发送消息的顺序一致
+	mu      sync.Mutex // 保护下面的字段，不会在发送消息时持有
+	next    uint64     // 下一条消息的 delivery tag
+	pending []*pending // 等待确认的消息，按 delivery tag 排序
+	closed  bool
+}
+
+// pending 一条等待确认的消息
+type pending struct {
+	tag      uint64
+	body     []byte // 用于找到被退回的消息
+	done     chan error
+	returned *amqp.Return // 收到 ack 之前被退回
+}
+
+// newConfirms 从 notify 中读取确认，从 returns 中读取被退回的消息，
+// notify 关闭时所有等待中的消息返回 ErrConfirmClosed
+func newConfirms(notify <-chan amqp.Confirmation, returns <-chan amqp.Return) *confirms {
+	c := &confirms{next: 1}
+	go c.listen(notify, returns)
+	return c
+}
+
+// publish 调用 fn 发送 body 并记录 delivery tag，返回的 chan 会收到这条消息的结果，
+// ack 时为 nil，nack 时为 *NackError，被退回时为 *ReturnError
+// 发送之前先登记 delivery tag，发送时只持有 c.send，不会阻塞 listen 处理确认
+func (c *confirms) publish(body []byte, fn func() error) (uint64, <-chan error, error) {
+	c.send.Lock()
+	defer c.send.Unlock()
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return 0, nil, ErrConfirmClosed
+	}
+	p := &pending{tag: c.next, body: body, done: make(chan error, 1)}
+	c.pending = append(c.pending, p)
+	c.mu.Unlock()
+
+	if err := fn(); err != nil {
+		// 发送失败时 amqp 不会增加 delivery tag，这里也保持不变
+		c.remove(p.tag)
+		return 0, nil, err
+	}
+	c.mu.Lock()
+	c.next++
+	c.mu.Unlock()
+	return p.tag, p.done, nil
+}
+
+// remove 删除并返回 tag 对应的消息
+func (c *confirms) remove(tag uint64) (*pending, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, p := range c.pending {
+		if p.tag == tag {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// wait 等待 tag 的确认，ctx 结束时不再等待，
+// 消息仍然保留到收到确认为止，之后的 basic.return 不会被当作其他消息的
+func (c *confirms) wait(ctx context.Context, tag uint64, done <-chan error) error {
+	select {
+	case err, ok := <-done:
+		if !ok {
+			return ErrConfirmClosed
+		}
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *confirms) listen(notify <-chan amqp.Confirmation, returns <-chan amqp.Return) {
+	for {
+		select {
+		case r, ok := <-returns:
+			if !ok {
+				returns = nil
+				continue
+			}
+			c.returned(r)
+		case confirm, ok := <-notify:
+			if !ok {
+				c.close()
+				return
+			}
+			// amqp 在同一个 goroutine 中按顺序分发 basic.return 和 basic.ack，
+			// 被退回的消息总是先于它的 ack 放入 returns，处理 ack 之前先读取已经收到的 return
+			c.drain(returns)
+			c.confirm(confirm)
+		}
+	}
+}
+
+// drain 读取 returns 中已经收到的消息，不会阻塞
+func (c *confirms) drain(returns <-chan amqp.Return) {
+	for {
+		select {
+		case r, ok := <-returns:
+			if !ok {
+				return
+			}
+			c.returned(r)
+		default:
+			return
+		}
+	}
+}
+
+// returned 记录被退回的消息，收到 ack 时返回 *ReturnError
+// basic.return 中没有 delivery tag，被退回的消息按发送的顺序到达，这里找到最早发送的、内容相同且没有被退回的消息，
+// 内容相同的消息的 exchange、routing key 都相同，路由的结果也相同
+func (c *confirms) returned(r amqp.Return) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, p := range c.pending {
+		if p.returned == nil && bytes.Equal(p.body, r.Body) {
+			p.returned = &r
+			return
+		}
+	}
+}
+
+func (c *confirms) confirm(confirm amqp.Confirmation) {
+	p, ok := c.remove(confirm.DeliveryTag)
+	if !ok {
+		return
+	}
+	switch {
+	case !confirm.Ack:
+		p.done <- &NackError{DeliveryTag: confirm.DeliveryTag}
+	case p.returned != nil:
+		p.done <- &ReturnError{
+			DeliveryTag: confirm.DeliveryTag,
+			ReplyCode:   p.returned.ReplyCode,
+			ReplyText:   p.returned.ReplyText,
+			Exchange:    p.returned.Exchange,
+			RoutingKey:  p.returned.RoutingKey,
+		}
+	default:
+		p.done <- nil
+	}
+}
+
+func (c *confirms) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for _, p := range c.pending {
+		close(p.done)
+	}
+	c.pending = nil
+}
@@ -1,6 +1,7 @@
 package mq
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/streadway/amqp"
 )
@@ -11,8 +12,19 @@ type RabbitMqProducer interface {
 	PurgeQueue() error
 }
 
+// ConfirmPublisher 开启 Config.Confirm 时可以使用的发送方法，NewMqProducer 返回的 producer 都实现了这个接口：
+//
+//	if p, ok := producer.(mq.ConfirmPublisher); ok {
+//		err = p.PublishWithConfirm(ctx, msg)
+//	}
+type ConfirmPublisher interface {
+	PublishWithConfirm(context.Context, interface{}) error
+}
+
 type Producer struct {
 	*mq
+
+	confirms *confirms // 开启 Config.Confirm 时不为 nil
 }
 
 func NewMqProducer(config *Config) (RabbitMqProducer, error) {
@@ -20,22 +32,70 @@ func NewMqProducer(config *Config) (RabbitMqProducer, error) {
 	if err := mq.init(); err != nil {
 		return nil, err
 	}
-	return &Producer{
+	producer := &Producer{
 		mq: mq,
-	}, nil
+	}
+	if config.Confirm {
+		// 消息以 mandatory 发送，没有匹配到队列时会被退回，需要在 ack 之前收到 basic.return
+		returns := mq.channel.NotifyReturn(make(chan amqp.Return, 64))
+		if err := mq.channel.Confirm(false); err != nil {
+			mq.stop()
+			return nil, err
+		}
+		producer.confirms = newConfirms(mq.channel.NotifyPublish(make(chan amqp.Confirmation, 64)), returns)
+	}
+	return producer, nil
 }
 
 func (producer *Producer) Destroy() {
 	producer.mq.stop()
 }
 
+// Publish 发送消息，开启 Config.Confirm 时等待 broker 确认，参考 PublishWithConfirm
 func (producer *Producer) Publish(msg interface{}) (err error) {
+	if producer.confirms != nil {
+		return producer.PublishWithConfirm(context.Background(), msg)
+	}
 
 	body, err := json.Marshal(msg)
 	if err != nil {
 		return
 	}
 
+	return producer.publish(body)
+}
+
+// PublishWithConfirm 发送消息并等待 broker 确认，需要开启 Config.Confirm
+// broker nack 时返回 *NackError，没有匹配到队列被退回时返回 *ReturnError，等待期间 channel 关闭时返回 ErrConfirmClosed，
+// ctx 结束或超过 Config.ConfirmTimeout 时返回 ctx.Err()，这几种情况消息都可能没有被接收，
+// 需要调用方重新发送
+func (producer *Producer) PublishWithConfirm(ctx context.Context, msg interface{}) error {
+	if producer.confirms == nil {
+		return ErrConfirmDisabled
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	timeout := producer.config.ConfirmTimeout
+	if timeout <= 0 {
+		timeout = defaultConfirmTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tag, done, err := producer.confirms.publish(body, func() error {
+		return producer.publish(body)
+	})
+	if err != nil {
+		return err
+	}
+	return producer.confirms.wait(ctx, tag, done)
+}
+
+func (producer *Producer) publish(body []byte) error {
 	return producer.channel.Publish(
 		producer.config.Exchange,
 		producer.config.RoutingKey,
@@ -1 +1,150 @@
 package mq
+
+import (
+	"context"
+	"errors"
+	"github.com/NingziSlay/pkg/config"
+	"github.com/streadway/amqp"
+	"testing"
+	"time"
+)
+
+func TestConfirms(t *testing.T) {
+	notify := make(chan amqp.Confirmation)
+	c := newConfirms(notify, nil)
+	ok := func() error { return nil }
+
+	tag1, wait1, err := c.publish(nil, ok)
+	if err != nil || tag1 != 1 {
+		t.Fatalf("publish: tag %d, err %v", tag1, err)
+	}
+	// 发送失败时不占用 delivery tag
+	if _, _, err := c.publish(nil, func() error { return amqp.ErrClosed }); err != amqp.ErrClosed {
+		t.Fatalf("want amqp.ErrClosed, got %v", err)
+	}
+	tag2, wait2, _ := c.publish(nil, ok)
+	if tag2 != 2 {
+		t.Fatalf("want tag 2, got %d", tag2)
+	}
+
+	notify <- amqp.Confirmation{DeliveryTag: 2, Ack: false}
+	notify <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+	if err := c.wait(context.Background(), tag1, wait1); err != nil {
+		t.Fatalf("want ack, got %v", err)
+	}
+	var nack *NackError
+	if err := c.wait(context.Background(), tag2, wait2); !errors.As(err, &nack) || nack.DeliveryTag != 2 {
+		t.Fatalf("want NackError for tag 2, got %v", err)
+	}
+}
+
+func TestConfirmsTimeout(t *testing.T) {
+	notify := make(chan amqp.Confirmation)
+	c := newConfirms(notify, nil)
+	tag, wait, _ := c.publish(nil, func() error { return nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.wait(ctx, tag, wait); err != context.DeadlineExceeded {
+		t.Fatalf("want context.DeadlineExceeded, got %v", err)
+	}
+	// 超时之后的确认直接丢弃
+	notify <- amqp.Confirmation{DeliveryTag: tag, Ack: true}
+}
+
+func TestConfirmsClosed(t *testing.T) {
+	notify := make(chan amqp.Confirmation)
+	c := newConfirms(notify, nil)
+	tag, wait, _ := c.publish(nil, func() error { return nil })
+
+	close(notify)
+	if err := c.wait(context.Background(), tag, wait); err != ErrConfirmClosed {
+		t.Fatalf("want ErrConfirmClosed, got %v", err)
+	}
+	// listen 退出之后不能再发送
+	for i := 0; ; i++ {
+		if _, _, err := c.publish(nil, func() error { return nil }); err == ErrConfirmClosed {
+			break
+		}
+		if i > 100 {
+			t.Fatalf("publish after close should fail")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// 没有匹配到队列的消息先被退回，然后被 ack，前面的消息还没有 ack 时按内容找到被退回的消息
+func TestConfirmsReturned(t *testing.T) {
+	notify := make(chan amqp.Confirmation)
+	returns := make(chan amqp.Return, 1)
+	c := newConfirms(notify, returns)
+	ok := func() error { return nil }
+
+	tag1, done1, _ := c.publish([]byte(`{"id":1}`), ok)
+	tag2, done2, _ := c.publish([]byte(`{"id":2}`), ok)
+	returns <- amqp.Return{Body: []byte(`{"id":2}`), ReplyCode: amqp.NoRoute, ReplyText: "NO_ROUTE", RoutingKey: "billing"}
+	notify <- amqp.Confirmation{DeliveryTag: tag1, Ack: true}
+	notify <- amqp.Confirmation{DeliveryTag: tag2, Ack: true}
+
+	if err := c.wait(context.Background(), tag1, done1); err != nil {
+		t.Fatalf("want ack, got %v", err)
+	}
+	var returned *ReturnError
+	err := c.wait(context.Background(), tag2, done2)
+	if !errors.As(err, &returned) || returned.DeliveryTag != tag2 || returned.ReplyCode != amqp.NoRoute || returned.RoutingKey != "billing" {
+		t.Fatalf("want ReturnError, got %v", err)
+	}
+
+	// 其他消息不受影响
+	tag, done, _ := c.publish([]byte(`{"id":2}`), ok)
+	notify <- amqp.Confirmation{DeliveryTag: tag, Ack: true}
+	if err := c.wait(context.Background(), tag, done); err != nil {
+		t.Fatalf("want ack, got %v", err)
+	}
+}
+
+// 等待超时的消息收到确认之前仍然保留，之后的 basic.return 不会被当作其他消息的
+func TestConfirmsReturnedAfterTimeout(t *testing.T) {
+	notify := make(chan amqp.Confirmation)
+	returns := make(chan amqp.Return, 1)
+	c := newConfirms(notify, returns)
+	ok := func() error { return nil }
+
+	tag1, done1, _ := c.publish([]byte("same"), ok)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := c.wait(ctx, tag1, done1); err != context.DeadlineExceeded {
+		t.Fatalf("want context.DeadlineExceeded, got %v", err)
+	}
+	tag2, done2, _ := c.publish([]byte("same"), ok)
+	returns <- amqp.Return{Body: []byte("same"), ReplyCode: amqp.NoRoute}
+	notify <- amqp.Confirmation{DeliveryTag: tag1, Ack: true}
+	notify <- amqp.Confirmation{DeliveryTag: tag2, Ack: true}
+	if err := c.wait(context.Background(), tag2, done2); err != nil {
+		t.Fatalf("want ack, got %v", err)
+	}
+}
+
+// 严格模式下 Config 中新增的字段有默认值
+func TestConfigStrict(t *testing.T) {
+	var c Config
+	err := config.MustMapFrom(&c, map[string]string{
+		"ADDR":            "amqp://localhost:5672/",
+		"EXCHANGE":        "billing",
+		"EXCHANGE_TYPE":   "topic",
+		"QUEUE":           "billing",
+		"ROUTING_KEY":     "billing.#",
+		"CONSUMER_TAG":    "billing",
+		"PREFETCH_COUNT":  "1",
+		"PREFETCH_SIZE":   "0",
+		"EXCHANGE_ARGS":   "x=1",
+		"QUEUE_ARGS":      "x=1",
+		"QUEUE_BIND_ARGS": "x=1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Confirm || c.ConfirmTimeout != defaultConfirmTimeout {
+		t.Fatalf("unexpected value: %+v", c)
+	}
+}